@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nitwhiz/quadis-server/pkg/field"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
+	"github.com/nitwhiz/quadis-server/pkg/room"
+	"os"
+	"strings"
+)
+
+// replay-verify simulates the games of replay files again and checks they end up where the recording says they did.
+// A divergence is reported at the first tick the state differs, with the inputs of the tick and how it changed the game.
+// Replays without state hashes are only compared at every lock, then the first divergent lock is reported together
+// with the last lock both agree on.
+// It exits with 1 if a replay diverges and with 2 if a replay cannot be read.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: replay-verify <replay file>...")
+		os.Exit(2)
+	}
+
+	exitCode := 0
+
+	for _, path := range os.Args[1:] {
+		ok, err := verifyFile(path)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			exitCode = 2
+
+			continue
+		}
+
+		if !ok && exitCode == 0 {
+			exitCode = 1
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
+func verifyFile(path string) (bool, error) {
+	rep, err := replay.Open(path)
+
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+
+	for _, round := range rep.Rounds {
+		rules, err := room.ParseRules(round.Rules)
+
+		if err != nil {
+			return false, err
+		}
+
+		d, err := replay.Verify(round, rules.ToGameConfig())
+
+		if err != nil {
+			return false, err
+		}
+
+		if d == nil {
+			fmt.Printf("%s: round %d ok, %d games\n", path, round.Number, len(round.Games))
+			continue
+		}
+
+		ok = false
+
+		if !d.Exact {
+			fmt.Printf(
+				"%s: round %d, game %s (%s): first divergent lock at tick %d, equal up to tick %d\n",
+				path, round.Number, d.GameId, d.PlayerName, d.Tick, d.SinceTick,
+			)
+
+			printDiff(rules, d)
+
+			continue
+		}
+
+		fmt.Printf("%s: round %d, game %s (%s): first divergent tick %d\n", path, round.Number, d.GameId, d.PlayerName, d.Tick)
+
+		diff, err := replay.GetTickDiff(round, rules.ToGameConfig(), d.GameId, d.Tick)
+
+		if err != nil {
+			return false, err
+		}
+
+		printTickDiff(rules, diff)
+	}
+
+	return ok, nil
+}
+
+// printTickDiff shows the simulated state before the tick, which the recording agrees with, next to the state after it
+func printTickDiff(rules *room.Rules, diff *replay.TickDiff) {
+	if len(diff.Inputs) == 0 {
+		fmt.Println("  no inputs at this tick")
+	}
+
+	for _, input := range diff.Inputs {
+		fmt.Printf("  input %+v\n", *input)
+	}
+
+	before := diff.Before
+	after := diff.After
+
+	fmt.Printf("  %-8s %12s %12s\n", "", "before", "simulated")
+	fmt.Printf("  %-8s %12d %12d\n", "tick", before.Tick, after.Tick)
+	fmt.Printf("  %-8s %12d %12d\n", "score", before.Score, after.Score)
+	fmt.Printf("  %-8s %12d %12d\n", "lines", before.Lines, after.Lines)
+	fmt.Printf("  %-8s %12d %12d\n", "level", before.Level, after.Level)
+	fmt.Printf("  %-8s %12d %12d\n", "piece", before.Piece, after.Piece)
+	fmt.Printf("  %-8s %12d %12d\n", "rotation", before.Rotation, after.Rotation)
+	fmt.Printf("  %-8s %12d %12d\n", "x", before.X, after.X)
+	fmt.Printf("  %-8s %12d %12d\n", "y", before.Y, after.Y)
+
+	printFields(rules, before.Field, after.Field)
+}
+
+func printDiff(rules *room.Rules, d *replay.Divergence) {
+	var recorded, simulated replay.Result
+
+	if d.Recorded != nil {
+		recorded = *d.Recorded
+	} else {
+		fmt.Println("  the recording has no state here")
+	}
+
+	if d.Simulated != nil {
+		simulated = *d.Simulated
+	} else {
+		fmt.Println("  the simulation has no state here")
+	}
+
+	fmt.Printf("  %-6s %12s %12s\n", "", "recorded", "simulated")
+	fmt.Printf("  %-6s %12d %12d\n", "tick", recorded.Tick, simulated.Tick)
+	fmt.Printf("  %-6s %12d %12d\n", "score", recorded.Score, simulated.Score)
+	fmt.Printf("  %-6s %12d %12d\n", "lines", recorded.Lines, simulated.Lines)
+	fmt.Printf("  %-6s %12d %12d\n", "level", recorded.Level, simulated.Level)
+
+	printFields(rules, recorded.Field, simulated.Field)
+}
+
+// printFields shows two fields side by side, rows which differ are marked
+func printFields(rules *room.Rules, left string, right string) {
+	leftRows := getFieldRows(rules, left)
+	rightRows := getFieldRows(rules, right)
+
+	fmt.Println("  field")
+
+	for y := 0; y < rules.FieldHeight; y++ {
+		marker := " "
+
+		if leftRows[y] != rightRows[y] {
+			marker = "*"
+		}
+
+		fmt.Printf("  %s %s  %s\n", marker, leftRows[y], rightRows[y])
+	}
+}
+
+// getFieldRows decodes a field payload, rows which cannot be decoded are shown as question marks
+func getFieldRows(rules *room.Rules, data string) []string {
+	f := field.New(&field.Settings{
+		Width:  rules.FieldWidth,
+		Height: rules.FieldHeight,
+	})
+
+	if data == "" || f.Decode64(strings.Split(data, " ")) != nil {
+		rows := make([]string, rules.FieldHeight)
+
+		for y := range rows {
+			rows[y] = strings.Repeat("?", rules.FieldWidth)
+		}
+
+		return rows
+	}
+
+	return f.GetRows()
+}
@@ -0,0 +1,127 @@
+package attack
+
+import "github.com/nitwhiz/quadis-server/pkg/piece"
+
+type Spin string
+
+const SpinNone = Spin("")
+const SpinMini = Spin("mini")
+const SpinFull = Spin("full")
+
+const perfectClearAttack = 10
+const backToBackAttack = 1
+
+var lineAttacks = []int{0, 0, 1, 2, 4}
+var miniSpinAttacks = []int{0, 0, 1}
+var fullSpinAttacks = []int{0, 2, 4, 6}
+var comboAttacks = []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5}
+
+var lineTypes = []string{"none", "single", "double", "triple", "quad"}
+
+// tstKick is the index of the srs kick which always makes a full t-spin
+const tstKick = 4
+
+// Clear describes what a locked piece achieved
+type Clear struct {
+	Type  string `json:"type"`
+	Lines int    `json:"lines"`
+	Spin  Spin   `json:"spin"`
+	// Combo is the number of consecutive clears before this one
+	Combo        int  `json:"combo"`
+	BackToBack   bool `json:"backToBack"`
+	PerfectClear bool `json:"perfectClear"`
+	Attack       int  `json:"attack"`
+}
+
+// IsDifficult returns whether the clear continues a back-to-back chain
+func (c *Clear) IsDifficult() bool {
+	return c.Lines >= 4 || (c.Spin != SpinNone && c.Lines > 0)
+}
+
+func getType(lines int, spin Spin) string {
+	t := lineTypes[clampIndex(lines, len(lineTypes))]
+
+	switch spin {
+	case SpinMini:
+		return "tspin_mini_" + t
+	case SpinFull:
+		return "tspin_" + t
+	default:
+		return t
+	}
+}
+
+func getAttack(c *Clear) int {
+	a := 0
+
+	switch c.Spin {
+	case SpinMini:
+		a = miniSpinAttacks[clampIndex(c.Lines, len(miniSpinAttacks))]
+	case SpinFull:
+		a = fullSpinAttacks[clampIndex(c.Lines, len(fullSpinAttacks))]
+	default:
+		a = lineAttacks[clampIndex(c.Lines, len(lineAttacks))]
+	}
+
+	if c.BackToBack {
+		a += backToBackAttack
+	}
+
+	if c.Combo > 0 {
+		a += comboAttacks[clampIndex(c.Combo, len(comboAttacks))]
+	}
+
+	if c.PerfectClear {
+		a += perfectClearAttack
+	}
+
+	return a
+}
+
+func clampIndex(i int, length int) int {
+	if i >= length {
+		return length - 1
+	}
+
+	return i
+}
+
+// OccupiedFunc tells whether a cell is blocked, cells out of bounds are blocked
+type OccupiedFunc func(x int, y int) bool
+
+// DetectTSpin applies the 3-corner rule to a t piece at x, y which was last moved by the rotation kick lastKick, lastKick is -1 if it was not rotated last
+func DetectTSpin(p *piece.Piece, r piece.Rotation, x int, y int, lastKick int, occupied OccupiedFunc) Spin {
+	if p.Token != piece.TokenT || lastKick < 0 {
+		return SpinNone
+	}
+
+	// corners of the 3x3 box around the center of the t, clockwise from the top left
+	corners := [4]bool{
+		occupied(x, y),
+		occupied(x+2, y),
+		occupied(x+2, y+2),
+		occupied(x, y+2),
+	}
+
+	occupiedCount := 0
+
+	for _, c := range corners {
+		if c {
+			occupiedCount++
+		}
+	}
+
+	if occupiedCount < 3 {
+		return SpinNone
+	}
+
+	// the two corners next to the side the t points to
+	rot := p.ClampRotation(r)
+	frontCorners := corners[rot] && corners[(rot+1)%4]
+
+	if frontCorners || lastKick == tstKick {
+		return SpinFull
+	}
+
+	return SpinMini
+}
@@ -0,0 +1,47 @@
+package attack
+
+// Chain keeps track of combos and back-to-back clears across locked pieces
+type Chain struct {
+	combo      int
+	backToBack bool
+}
+
+func NewChain() *Chain {
+	return &Chain{
+		combo:      -1,
+		backToBack: false,
+	}
+}
+
+func (c *Chain) Reset() {
+	c.combo = -1
+	c.backToBack = false
+}
+
+// Next records a locked piece and returns what it achieved
+func (c *Chain) Next(lines int, spin Spin, perfectClear bool) *Clear {
+	lineClear := Clear{
+		Type:         getType(lines, spin),
+		Lines:        lines,
+		Spin:         spin,
+		Combo:        0,
+		BackToBack:   false,
+		PerfectClear: perfectClear,
+	}
+
+	if lines == 0 {
+		c.combo = -1
+
+		return &lineClear
+	}
+
+	c.combo++
+	lineClear.Combo = c.combo
+
+	lineClear.BackToBack = c.backToBack && lineClear.IsDifficult()
+	c.backToBack = lineClear.IsDifficult()
+
+	lineClear.Attack = getAttack(&lineClear)
+
+	return &lineClear
+}
@@ -0,0 +1,86 @@
+package attack
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	c := NewChain()
+
+	steps := []struct {
+		Lines          int
+		Spin           Spin
+		PerfectClear   bool
+		ExpectedType   string
+		ExpectedCombo  int
+		ExpectedB2B    bool
+		ExpectedAttack int
+	}{
+		{4, SpinNone, false, "quad", 0, false, 4},
+		{2, SpinFull, false, "tspin_double", 1, true, 4 + 1 + 0},
+		{1, SpinNone, false, "single", 2, false, 0 + 1},
+		{0, SpinNone, false, "none", 0, false, 0},
+		{4, SpinNone, true, "quad", 0, false, 4 + 10},
+		{1, SpinMini, false, "tspin_mini_single", 1, true, 0 + 1 + 0},
+	}
+
+	for i, s := range steps {
+		lineClear := c.Next(s.Lines, s.Spin, s.PerfectClear)
+
+		if lineClear.Type != s.ExpectedType {
+			t.Fatalf("step #%d: expected type %s, got %s", i, s.ExpectedType, lineClear.Type)
+		}
+
+		if lineClear.Combo != s.ExpectedCombo {
+			t.Fatalf("step #%d: expected combo %d, got %d", i, s.ExpectedCombo, lineClear.Combo)
+		}
+
+		if lineClear.BackToBack != s.ExpectedB2B {
+			t.Fatalf("step #%d: expected back-to-back %t, got %t", i, s.ExpectedB2B, lineClear.BackToBack)
+		}
+
+		if lineClear.Attack != s.ExpectedAttack {
+			t.Fatalf("step #%d: expected attack %d, got %d", i, s.ExpectedAttack, lineClear.Attack)
+		}
+	}
+}
+
+// occupiedCells returns an OccupiedFunc for a 3x3 area at 0,0, everything around it is free
+func occupiedCells(cells ...[2]int) OccupiedFunc {
+	return func(x int, y int) bool {
+		for _, c := range cells {
+			if c[0] == x && c[1] == y {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func TestDetectTSpin(t *testing.T) {
+	// t pointing down into a slot, both bottom corners and one top corner are blocked
+	slot := occupiedCells([2]int{0, 0}, [2]int{0, 2}, [2]int{2, 2})
+
+	if s := DetectTSpin(&piece.T, piece.RotationReverse, 0, 0, 0, slot); s != SpinFull {
+		t.Fatalf("expected a full t-spin, got '%s'", s)
+	}
+
+	if s := DetectTSpin(&piece.T, piece.RotationReverse, 0, 0, -1, slot); s != SpinNone {
+		t.Fatalf("expected no t-spin without a rotation, got '%s'", s)
+	}
+
+	// t pointing up, only one front corner is blocked
+	if s := DetectTSpin(&piece.T, piece.RotationSpawn, 0, 0, 1, slot); s != SpinMini {
+		t.Fatalf("expected a mini t-spin, got '%s'", s)
+	}
+
+	if s := DetectTSpin(&piece.T, piece.RotationSpawn, 0, 0, tstKick, slot); s != SpinFull {
+		t.Fatalf("expected the tst kick to upgrade to a full t-spin, got '%s'", s)
+	}
+
+	if s := DetectTSpin(&piece.L, piece.RotationReverse, 0, 0, 0, slot); s != SpinNone {
+		t.Fatalf("expected no t-spin for other pieces, got '%s'", s)
+	}
+}
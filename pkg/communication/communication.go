@@ -9,7 +9,7 @@ import (
 	"time"
 )
 
-type PreStopCallback func()
+type PreStopCallback func(c *Connection)
 
 type Connection struct {
 	ws              *websocket.Conn
@@ -71,7 +71,7 @@ func (c *Connection) Stop() {
 	log.Println("stopping connection ...")
 
 	if c.preStopCallback != nil {
-		c.preStopCallback()
+		c.preStopCallback(c)
 	}
 
 	c.stop()
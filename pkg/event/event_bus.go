@@ -109,7 +109,12 @@ func (b *Bus) Unsubscribe(subscriberId string) {
 	}
 }
 
+// Publish sends the event to the subscribers, publishing on a nil bus does nothing
 func (b *Bus) Publish(event *Event) {
+	if b == nil {
+		return
+	}
+
 	event.PublishedAt = time.Now().UnixMilli()
 	b.channel <- event
 }
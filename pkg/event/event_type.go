@@ -6,7 +6,21 @@ const TypeHelloAck = "hello_ack"
 const TypeStart = "room_start"
 const TypeJoin = "room_join"
 const TypeLeave = "room_leave"
+const TypeDisconnect = "room_disconnect"
+const TypeReconnect = "room_reconnect"
 const TypeTargetsUpdate = "room_targets_update"
+const TypeRoomSnapshot = "room_snapshot"
+const TypeHostUpdate = "room_host_update"
+const TypeRulesUpdate = "room_rules_update"
+const TypeCommandError = "room_command_error"
+const TypeReadyUpdate = "room_ready_update"
+const TypeCountdown = "room_countdown"
+const TypeRoundResult = "round_result"
+const TypeMatchResult = "match_result"
+const TypeModeResult = "mode_result"
+const TypeTeamUpdate = "room_team_update"
+const TypeReplaySaved = "room_replay_saved"
+const TypePlaybackUpdate = "playback_update"
 
 const TypeItemUpdate = "item_update"
 const TypeItemAffectionUpdate = "item_affection_update"
@@ -15,7 +29,10 @@ const TypeFieldUpdate = "field_update"
 const TypeFallingPieceUpdate = "falling_piece_update"
 const TypeHoldingPieceUpdate = "holding_piece_update"
 const TypeNextPieceUpdate = "next_piece_update"
+const TypeNextQueueUpdate = "next_queue_update"
 const TypeScoreUpdate = "score_update"
+const TypeLineClear = "line_clear"
+const TypeIncomingAttackUpdate = "incoming_attack_update"
 const TypeGameOver = "game_over"
 const TypeRoomScores = "room_scores"
 
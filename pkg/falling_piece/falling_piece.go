@@ -3,6 +3,7 @@ package falling_piece
 import (
 	"github.com/nitwhiz/quadis-server/pkg/dirty"
 	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"math"
 	"sync"
 )
 
@@ -11,10 +12,18 @@ type FallingPiece struct {
 	x                   int
 	y                   int
 	rotation            piece.Rotation
-	speed               int64
-	fallTimer           int64
+	gravity             float64
+	fallProgress        float64
 	locked              bool
 	rotationLocked      bool
+	lastKick            int
+	ghostY              int
+	grounded            bool
+	lowestY             int
+	lockDelay           int64
+	lockTimer           int64
+	maxLockResets       int
+	lockResets          int
 	Dirty               *dirty.Dirtiness
 	mu                  *sync.RWMutex
 	collisionCheckMutex *sync.Mutex
@@ -26,6 +35,12 @@ type Payload struct {
 	Rotation       piece.Rotation `json:"rotation"`
 	X              int            `json:"x"`
 	Y              int            `json:"y"`
+	GhostY         int            `json:"ghostY"`
+	Grounded       bool           `json:"grounded"`
+	// LockDelay and LockTimer are measured in ticks
+	LockDelay      int64 `json:"lockDelay"`
+	LockTimer      int64 `json:"lockTimer"`
+	LockResetsLeft int   `json:"lockResetsLeft"`
 }
 
 func New(piece *piece.Piece) *FallingPiece {
@@ -34,9 +49,10 @@ func New(piece *piece.Piece) *FallingPiece {
 		x:                   0,
 		y:                   0,
 		rotation:            0,
-		speed:               1,
-		fallTimer:           1000,
+		gravity:             0,
+		fallProgress:        0,
 		locked:              false,
+		lastKick:            -1,
 		Dirty:               dirty.New(),
 		mu:                  &sync.RWMutex{},
 		collisionCheckMutex: &sync.Mutex{},
@@ -67,6 +83,11 @@ func (p *FallingPiece) ToPayload() *Payload {
 		Rotation:       p.rotation,
 		X:              p.x,
 		Y:              p.y,
+		GhostY:         p.ghostY,
+		Grounded:       p.grounded,
+		LockDelay:      p.lockDelay,
+		LockTimer:      p.lockTimer,
+		LockResetsLeft: p.maxLockResets - p.lockResets,
 	}
 }
 
@@ -74,7 +95,7 @@ func (p *FallingPiece) Lock() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.fallTimer = 0
+	p.fallProgress = 0
 	p.locked = true
 }
 
@@ -107,21 +128,22 @@ func (p *FallingPiece) GetPieceAndPosition() (*piece.Piece, piece.Rotation, int,
 	return p.piece, p.rotation, p.x, p.y
 }
 
-func (p *FallingPiece) GetNextPosition(delta int64) (bool, int) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+// GetFallDistance returns how many whole cells the piece falls within the ticks, keeping the fraction for later
+func (p *FallingPiece) GetFallDistance(ticks int64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	if p.piece != nil && !p.locked {
-		p.fallTimer -= delta
+	if p.piece == nil || p.locked {
+		return 0
+	}
 
-		if p.fallTimer <= 0 {
-			p.fallTimer = 1000 / p.speed
+	// gravity is measured in cells per frame, a tick is exactly one frame
+	p.fallProgress += p.gravity * float64(ticks)
 
-			return true, p.y + 1
-		}
-	}
+	distance := math.Floor(p.fallProgress)
+	p.fallProgress -= distance
 
-	return false, p.y
+	return int(distance)
 }
 
 func (p *FallingPiece) GetPiece() *piece.Piece {
@@ -141,20 +163,103 @@ func (p *FallingPiece) SetPiece(piece *piece.Piece, x int, y int, r piece.Rotati
 	p.y = y
 	p.rotation = r
 
-	p.speed = 1
-	p.fallTimer = 1000 / p.speed
+	p.fallProgress = 0
 
 	p.locked = false
+	p.lastKick = -1
+
+	p.grounded = false
+	p.lowestY = y
+	p.lockTimer = p.lockDelay
+	p.lockResets = 0
 
 	p.Dirty.Trip()
 }
 
+// SetGravity sets the gravity in G, which is cells per frame
+func (p *FallingPiece) SetGravity(g float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gravity = g
+}
+
 func (p *FallingPiece) SetY(y int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.y != y {
+		p.lastKick = -1
+	}
+
 	p.y = y
 
+	if y > p.lowestY {
+		p.lowestY = y
+		p.lockTimer = p.lockDelay
+		p.lockResets = 0
+	}
+
+	p.Dirty.Trip()
+}
+
+// SetGhostY sets the y the piece would land at if it was hard locked
+func (p *FallingPiece) SetGhostY(y int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ghostY != y {
+		p.ghostY = y
+		p.Dirty.Trip()
+	}
+}
+
+// SetLockDelay sets the ticks a grounded piece waits before locking and how often moving it resets that time
+func (p *FallingPiece) SetLockDelay(delay int64, maxResets int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lockDelay = delay
+	p.lockTimer = delay
+	p.maxLockResets = maxResets
+}
+
+func (p *FallingPiece) SetGrounded(grounded bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.grounded != grounded {
+		p.grounded = grounded
+		p.Dirty.Trip()
+	}
+}
+
+// UpdateLockTimer counts down the lock delay of a grounded piece by the ticks, returns true if it ran out
+func (p *FallingPiece) UpdateLockTimer(ticks int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.piece == nil || p.locked || !p.grounded {
+		return false
+	}
+
+	p.lockTimer -= ticks
+
+	return p.lockTimer <= 0
+}
+
+// ResetLockTimer restarts the lock delay after a move of a grounded piece, as long as there are resets left
+func (p *FallingPiece) ResetLockTimer() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.grounded || p.lockResets >= p.maxLockResets {
+		return
+	}
+
+	p.lockResets++
+	p.lockTimer = p.lockDelay
+
 	p.Dirty.Trip()
 }
 
@@ -165,6 +270,28 @@ func (p *FallingPiece) SetPosition(r piece.Rotation, x int, y int) {
 	p.rotation = r
 	p.x = x
 	p.y = y
+	p.lastKick = -1
+
+	p.Dirty.Trip()
+}
+
+// SetRotatedPosition is SetPosition for rotations, it remembers the kick used to tell spins apart later
+func (p *FallingPiece) SetRotatedPosition(r piece.Rotation, x int, y int, kick int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rotation = r
+	p.x = x
+	p.y = y
+	p.lastKick = kick
 
 	p.Dirty.Trip()
 }
+
+// GetLastKick returns the index of the kick of the last rotation, -1 if the piece moved since
+func (p *FallingPiece) GetLastKick() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastKick
+}
@@ -0,0 +1,107 @@
+package falling_piece
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+)
+
+// lockStep lowers the piece to y if set, resets the lock timer if reset is set and then counts down the ticks
+type lockStep struct {
+	y       int
+	reset   bool
+	ticks   int64
+	expired bool
+}
+
+func TestLockTimer(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     int64
+		maxResets int
+		airborne  bool
+		steps     []lockStep
+	}{
+		{
+			name:  "expires after the delay",
+			delay: 30,
+			steps: []lockStep{{ticks: 29}, {ticks: 1, expired: true}},
+		},
+		{
+			name:  "expires on the first tick without a delay",
+			delay: 0,
+			steps: []lockStep{{ticks: 1, expired: true}},
+		},
+		{
+			name:      "resets restart the delay",
+			delay:     30,
+			maxResets: 15,
+			steps:     []lockStep{{ticks: 29}, {reset: true, ticks: 29}, {reset: true, ticks: 29}, {ticks: 1, expired: true}},
+		},
+		{
+			name:      "resets are capped",
+			delay:     30,
+			maxResets: 2,
+			steps:     []lockStep{{ticks: 20}, {reset: true, ticks: 20}, {reset: true, ticks: 20}, {reset: true, ticks: 10, expired: true}},
+		},
+		{
+			name:      "a new lowest y restores the resets",
+			delay:     30,
+			maxResets: 1,
+			steps:     []lockStep{{reset: true, ticks: 20}, {y: 5, ticks: 20}, {reset: true, ticks: 29}, {reset: true, ticks: 1, expired: true}},
+		},
+		{
+			name:      "an airborne piece does not lock",
+			delay:     30,
+			maxResets: 1,
+			airborne:  true,
+			steps:     []lockStep{{ticks: 100}, {reset: true, ticks: 100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(&piece.T)
+			p.SetLockDelay(tt.delay, tt.maxResets)
+			p.SetGrounded(!tt.airborne)
+
+			for i, step := range tt.steps {
+				if step.y != 0 {
+					p.SetY(step.y)
+				}
+
+				if step.reset {
+					p.ResetLockTimer()
+				}
+
+				if expired := p.UpdateLockTimer(step.ticks); expired != step.expired {
+					t.Fatalf("expected the timer to be expired %t after step %d, lock timer is at %d", step.expired, i, p.ToPayload().LockTimer)
+				}
+			}
+		})
+	}
+}
+
+func TestLockResetsLeft(t *testing.T) {
+	p := New(&piece.T)
+	p.SetLockDelay(30, 2)
+	p.SetGrounded(true)
+
+	p.ResetLockTimer()
+
+	if left := p.ToPayload().LockResetsLeft; left != 1 {
+		t.Fatalf("expected 1 reset left, got %d", left)
+	}
+
+	p.ResetLockTimer()
+	p.ResetLockTimer()
+
+	if left := p.ToPayload().LockResetsLeft; left != 0 {
+		t.Fatalf("expected no reset left, got %d", left)
+	}
+
+	p.SetY(3)
+
+	if left := p.ToPayload().LockResetsLeft; left != 2 {
+		t.Fatalf("expected the resets to be restored, got %d", left)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/nitwhiz/quadis-server/pkg/dirty"
 	"github.com/nitwhiz/quadis-server/pkg/piece"
 	"github.com/nitwhiz/quadis-server/pkg/rng"
+	"hash"
 	"strings"
 	"sync"
 )
@@ -12,18 +13,26 @@ type Settings struct {
 	Seed   int64
 	Width  int
 	Height int
+	// GarbageHoles is the number of open columns in each garbage row
+	GarbageHoles int
+	// GarbageMessiness is the probability of the holes moving to other columns from one garbage row to the next
+	GarbageMessiness float64
 }
 
 type Field struct {
 	data    []piece.Token
 	centerX int
 	// todo: this is more or less a second source of truth
-	currentBedrock int
-	Dirty          *dirty.Dirtiness
-	mu             *sync.RWMutex
-	random         *rng.Basic
-	width          int
-	height         int
+	currentBedrock   int
+	Dirty            *dirty.Dirtiness
+	mu               *sync.RWMutex
+	random           *rng.Basic
+	width            int
+	height           int
+	garbageRandom    *rng.Basic
+	garbageHoles     []int
+	garbageHoleCount int
+	garbageMessiness float64
 }
 
 type Payload struct {
@@ -31,14 +40,20 @@ type Payload struct {
 }
 
 func New(settings *Settings) *Field {
+	random := rng.NewBasic(settings.Seed)
+
 	return &Field{
-		data:    make([]piece.Token, settings.Width*settings.Height),
-		centerX: settings.Width/2 - piece.BodyWidth/2,
-		Dirty:   dirty.New(),
-		mu:      &sync.RWMutex{},
-		random:  rng.NewBasic(settings.Seed),
-		width:   settings.Width,
-		height:  settings.Height,
+		data:             make([]piece.Token, settings.Width*settings.Height),
+		centerX:          settings.Width/2 - piece.BodyWidth/2,
+		Dirty:            dirty.New(),
+		mu:               &sync.RWMutex{},
+		random:           random,
+		width:            settings.Width,
+		height:           settings.Height,
+		garbageRandom:    rng.NewBasic(random.NextInt64()),
+		garbageHoles:     nil,
+		garbageHoleCount: settings.GarbageHoles,
+		garbageMessiness: settings.GarbageMessiness,
 	}
 }
 
@@ -66,6 +81,13 @@ func (f *Field) GetCurrentBedrock() int {
 	return f.currentBedrock
 }
 
+func (f *Field) GetWidth() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.width
+}
+
 func (f *Field) GetHeight() int {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -73,15 +95,102 @@ func (f *Field) GetHeight() int {
 	return f.height
 }
 
+// SetSeed restarts the randomness of the field, a field reset with the same seed behaves the same
+func (f *Field) SetSeed(seed int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.random = rng.NewBasic(seed)
+	f.garbageRandom = rng.NewBasic(f.random.NextInt64())
+}
+
+// GetStackHeight returns the number of rows between the highest occupied cell and the floor
+func (f *Field) GetStackHeight() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			if f.getDataXY(x, y) != piece.TokenNone {
+				return f.height - y
+			}
+		}
+	}
+
+	return 0
+}
+
 func (f *Field) Reset() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	f.data = make([]piece.Token, f.width*f.height)
+	f.currentBedrock = 0
+	f.garbageHoles = nil
 
 	f.Dirty.Trip()
 }
 
+// IsOccupied returns whether the cell is blocked, cells out of bounds are blocked
+func (f *Field) IsOccupied(x int, y int) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return !f.isInBounds(x, y) || f.getDataXY(x, y) != piece.TokenNone
+}
+
+// IsEmpty returns whether the field contains nothing but bedrock
+func (f *Field) IsEmpty() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, d := range f.data {
+		if d != piece.TokenNone && d != piece.TokenBedrock {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteHash writes every cell of the field to the hash
+func (f *Field) WriteHash(h hash.Hash) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	cells := make([]byte, len(f.data))
+
+	for i, d := range f.data {
+		cells[i] = byte(d)
+	}
+
+	_, _ = h.Write(cells)
+}
+
+// GetRows renders the field row by row from the top, empty cells are dots and occupied cells their token digit
+func (f *Field) GetRows() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	rows := make([]string, f.height)
+
+	for y := 0; y < f.height; y++ {
+		var row strings.Builder
+
+		for x := 0; x < f.width; x++ {
+			if t := f.getDataXY(x, y); t == piece.TokenNone {
+				row.WriteByte('.')
+			} else {
+				row.WriteByte('0' + byte(t))
+			}
+		}
+
+		rows[y] = row.String()
+	}
+
+	return rows
+}
+
 func (f *Field) isInBounds(x int, y int) bool {
 	if x < 0 || x >= f.width || y < 0 || y >= f.height {
 		return false
@@ -226,6 +335,51 @@ func (f *Field) IncreaseBedrock(delta int) {
 	f.currentBedrock = target
 }
 
+// nextGarbageHoles returns the open columns of the next garbage row
+func (f *Field) nextGarbageHoles() []int {
+	if f.garbageHoles != nil && !f.garbageRandom.Probably(f.garbageMessiness) {
+		return f.garbageHoles
+	}
+
+	columns := f.garbageRandom.Perm(f.width)
+
+	f.garbageHoles = columns[:f.garbageHoleCount]
+
+	return f.garbageHoles
+}
+
+// AddGarbage pushes the field up and adds garbage rows above the bedrock, each of them with holes to clear them
+func (f *Field) AddGarbage(lines int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	top := f.height - f.currentBedrock
+
+	for y := 0; y < top; y++ {
+		for x := 0; x < f.width; x++ {
+			if f.isInBounds(x, y-lines) {
+				f.setDataXY(x, y-lines, f.getDataXY(x, y), true)
+			}
+		}
+	}
+
+	for y := top - lines; y < top; y++ {
+		if y < 0 {
+			continue
+		}
+
+		holes := f.nextGarbageHoles()
+
+		for x := 0; x < f.width; x++ {
+			f.setDataXY(x, y, piece.TokenGarbage, true)
+		}
+
+		for _, x := range holes {
+			f.setDataXY(x, y, piece.TokenNone, true)
+		}
+	}
+}
+
 func (f *Field) decreaseBedrock(delta int) {
 	target := f.currentBedrock - delta
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/nitwhiz/quadis-server/pkg/piece"
 	"math"
+	"math/bits"
 	"strconv"
 )
 
@@ -30,18 +31,9 @@ func getCodec64() *Codec64 {
 	}
 }
 
+// getBitCount returns the number of bits needed to represent n
 func getBitCount(n uint64) int {
-	b := 1
-
-	for i := uint64(1); i < math.MaxUint64; i *= 2 {
-		if i >= n {
-			break
-		}
-
-		b++
-	}
-
-	return b
+	return bits.Len64(n)
 }
 
 func getMask(b int) uint64 {
@@ -0,0 +1,77 @@
+package field
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+)
+
+func TestAddGarbage(t *testing.T) {
+	f := New(&Settings{
+		Seed:             0,
+		Width:            10,
+		Height:           20,
+		GarbageHoles:     1,
+		GarbageMessiness: 0,
+	})
+
+	f.AddGarbage(3)
+
+	hole := -1
+
+	for y := 17; y < 20; y++ {
+		holeCount := 0
+
+		for x := 0; x < 10; x++ {
+			switch f.getDataXY(x, y) {
+			case piece.TokenNone:
+				holeCount++
+
+				if hole != -1 && hole != x {
+					t.Fatalf("expected the hole to stay in column %d without messiness, got %d", hole, x)
+				}
+
+				hole = x
+			case piece.TokenGarbage:
+				break
+			default:
+				t.Fatalf("unexpected token at %d,%d", x, y)
+			}
+		}
+
+		if holeCount != 1 {
+			t.Fatalf("expected 1 hole in row %d, got %d", y, holeCount)
+		}
+	}
+
+	f.setDataXY(hole, 19, piece.TokenI, true)
+
+	if cleared := f.ClearLines(); cleared != 1 {
+		t.Fatalf("expected the filled garbage row to clear, got %d cleared lines", cleared)
+	}
+}
+
+func TestGarbageHolesCoverEveryColumn(t *testing.T) {
+	holes := make([]int, 10)
+
+	for seed := int64(0); seed < 200; seed++ {
+		f := New(&Settings{
+			Seed:             seed,
+			Width:            10,
+			Height:           20,
+			GarbageHoles:     1,
+			GarbageMessiness: 1,
+		})
+
+		for row := 0; row < 5; row++ {
+			for _, x := range f.nextGarbageHoles() {
+				holes[x]++
+			}
+		}
+	}
+
+	for x, count := range holes {
+		if count == 0 {
+			t.Fatalf("expected column %d to become a hole, got %v", x, holes)
+		}
+	}
+}
@@ -2,22 +2,28 @@ package game
 
 import (
 	"context"
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/attack"
 	"github.com/nitwhiz/quadis-server/pkg/communication"
 	"github.com/nitwhiz/quadis-server/pkg/event"
 	"github.com/nitwhiz/quadis-server/pkg/falling_piece"
 	"github.com/nitwhiz/quadis-server/pkg/field"
+	"github.com/nitwhiz/quadis-server/pkg/gravity"
 	"github.com/nitwhiz/quadis-server/pkg/piece"
 	"github.com/nitwhiz/quadis-server/pkg/player"
+	"github.com/nitwhiz/quadis-server/pkg/rotation"
 	"github.com/nitwhiz/quadis-server/pkg/score"
-	"math"
 	"sync"
 	"time"
 )
 
-type OverCallback func()
+type OverCallback func(g *Game)
 
 type ActivateItemCallback func(g *Game)
 
+// RoomCommandCallback receives the commands of the player which are meant for the room
+type RoomCommandCallback func(g *Game, cmd string)
+
 type Settings struct {
 	Id                   string
 	EventBus             *event.Bus
@@ -27,89 +33,226 @@ type Settings struct {
 	ParentContext        context.Context
 	OverCallback         OverCallback
 	ActivateItemCallback ActivateItemCallback
+	RoomCommandCallback  RoomCommandCallback
 	Seed                 int64
-	IsHost               bool
+	ResumeToken          string
+	Config               *Config
+	// Headless games run no goroutines, they are stepped by the caller and may have no event bus
+	Headless bool
 }
 
 type Game struct {
 	id                   string
+	config               *Config
 	player               *player.Player
 	fallingPiece         *falling_piece.FallingPiece
-	nextPiece            *piece.LivingPiece
+	nextPieces           *piece.Queue
 	holdingPiece         *piece.LivingPiece
 	field                *field.Field
 	bus                  *event.Bus
 	over                 bool
 	score                *score.Score
-	lastUpdate           *int64
+	startAt              time.Time
+	tick                 int64
+	pendingInputs        []*Input
+	inputLog             []*Input
+	checkpoints          []*Checkpoint
+	stateHashes          []*StateHash
+	inputMu              *sync.Mutex
+	lastAttackerId       string
+	shared               *Shared
+	piecesPlaced         int
+	completed            bool
 	pieceGenerator       *piece.Generator
+	newPieceGenerator    piece.GeneratorFactory
 	ctx                  context.Context
 	stop                 context.CancelFunc
 	wg                   *sync.WaitGroup
 	mu                   *sync.RWMutex
 	con                  *communication.Connection
+	disconnectedAt       time.Time
+	resumeToken          string
 	bedrockChannel       chan *Bedrock
+	incoming             *Incoming
+	attackChain          *attack.Chain
 	overCallback         OverCallback
 	activateItemCallback ActivateItemCallback
+	roomCommandCallback  RoomCommandCallback
 	lastActivity         time.Time
-	host                 bool
-	overridePiece        *piece.Piece
+	seed                 int64
+	gravityCurve         gravity.Curve
+	rotationSystem       rotation.System
+	// commandTime is the time of the last command applied in the current tick, finishTime the time in ms the game was finished at
+	commandTime int64
+	finishTime  int64
 }
 
 type Payload struct {
 	Id         string `json:"id"`
 	PlayerName string `json:"playerName"`
+	Connected  bool   `json:"connected"`
+	Host       bool   `json:"host"`
+	Ready      bool   `json:"ready"`
+	Team       int    `json:"team"`
 }
 
-func New(settings *Settings) *Game {
-	f := field.New(&field.Settings{
-		Seed:   settings.Seed,
-		Width:  10,
-		Height: 20,
-	})
-	s := score.New()
+func New(settings *Settings) (*Game, error) {
+	parentContext := settings.ParentContext
 
-	ctx, cancel := context.WithCancel(settings.ParentContext)
+	if parentContext == nil {
+		parentContext = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(parentContext)
 
 	g := Game{
 		id:                   settings.Id,
 		player:               settings.Player,
 		fallingPiece:         nil,
-		nextPiece:            nil,
+		nextPieces:           nil,
 		holdingPiece:         nil,
-		field:                f,
 		bus:                  settings.EventBus,
 		over:                 true,
-		score:                s,
-		lastUpdate:           nil,
 		pieceGenerator:       nil,
 		wg:                   &sync.WaitGroup{},
 		mu:                   &sync.RWMutex{},
+		inputMu:              &sync.Mutex{},
 		con:                  settings.Connection,
+		resumeToken:          settings.ResumeToken,
 		bedrockChannel:       settings.BedrockChannel,
 		ctx:                  ctx,
 		stop:                 cancel,
 		overCallback:         settings.OverCallback,
 		activateItemCallback: settings.ActivateItemCallback,
+		roomCommandCallback:  settings.RoomCommandCallback,
 		lastActivity:         time.Now(),
-		host:                 settings.IsHost,
+		seed:                 settings.Seed,
+	}
+
+	if err := g.configure(settings.Config); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if !settings.Headless {
+		go g.startCommandReader()
+		go g.startUpdater()
+	}
+
+	return &g, nil
+}
+
+// configure sets up everything depending on the config, the game must not be running
+func (g *Game) configure(config *Config) error {
+	gravityCurve, err := gravity.New(config.GravityCurve, config.GravityTable)
+
+	if err != nil {
+		return err
+	}
+
+	rotationSystem, err := rotation.Get(config.RotationSystem)
+
+	if err != nil {
+		return err
+	}
+
+	newPieceGenerator, err := piece.NewGeneratorFactory(config.Randomizer, config.RandomizerSequence)
+
+	if err != nil {
+		return err
+	}
+
+	g.config = config
+	g.gravityCurve = gravityCurve
+	g.rotationSystem = rotationSystem
+	g.newPieceGenerator = newPieceGenerator
+
+	g.field = field.New(&field.Settings{
+		Seed:             g.seed,
+		Width:            config.FieldWidth,
+		Height:           config.FieldHeight,
+		GarbageHoles:     config.GarbageHoles,
+		GarbageMessiness: config.GarbageMessiness,
+	})
+	g.score = score.New(config.StartLevel)
+	g.incoming = NewIncoming(msToTicks(config.GarbageDelay))
+	g.attackChain = attack.NewChain()
+
+	return nil
+}
+
+// SetConfig replaces the config of a game which is not running, it takes effect with the next start
+func (g *Game) SetConfig(config *Config) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.over {
+		return errors.New("game is running")
 	}
 
-	go g.startCommandReader()
-	go g.startUpdater()
+	// the co-op group is formed again with the next start
+	g.shared = nil
 
-	return &g
+	return g.configure(config)
+}
+
+func (g *Game) GetTeam() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.player.GetTeam()
 }
 
 func (g *Game) IsHost() bool {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	return g.host
+	return g.player.IsHost()
 }
 
-func (g *Game) activateItem() {
-	g.activateItemCallback(g)
+func (g *Game) GetResumeToken() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.resumeToken
+}
+
+func (g *Game) GetConnection() *communication.Connection {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.con
+}
+
+// SetConnection binds the game to another connection, nil marks the game as disconnected while it keeps running
+func (g *Game) SetConnection(c *communication.Connection) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.con = c
+
+	if c == nil {
+		g.disconnectedAt = time.Now()
+	} else {
+		g.disconnectedAt = time.Time{}
+		g.lastActivity = time.Now()
+	}
+}
+
+// SetEventBus replaces the bus the updates of the game are published on, nil publishes nothing
+func (g *Game) SetEventBus(bus *event.Bus) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.bus = bus
+}
+
+// GetDisconnectedAt returns when the game lost its connection, the zero time if it is connected
+func (g *Game) GetDisconnectedAt() time.Time {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.disconnectedAt
 }
 
 func (g *Game) GetField() *field.Field {
@@ -147,33 +290,75 @@ func (g *Game) GetFallingPiece() *falling_piece.FallingPiece {
 	return g.fallingPiece
 }
 
-func (g *Game) SetOverridePiece(piece *piece.Piece) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// SetOverridePiece makes every next piece the given piece from the next tick on, nil ends the override
+func (g *Game) SetOverridePiece(p *piece.Piece) {
+	input := &Input{
+		Type: InputTypeOverridePiece,
+	}
 
-	g.overridePiece = piece
+	if p != nil {
+		input.Token = p.Token
+	}
 
-	if piece != nil {
-		g.fallingPiece.SetPiece(piece, g.field.GetCenterX(), 0, 0)
-		g.nextPiece.SetPiece(piece)
-	} else {
-		g.nextPiece.SetPiece(g.pieceGenerator.NextElement())
+	g.QueueInput(input)
+}
+
+// SetRotationLocked keeps the falling piece from rotating from the next tick on
+func (g *Game) SetRotationLocked(locked bool) {
+	g.QueueInput(&Input{
+		Type:   InputTypeRotationLock,
+		Locked: locked,
+	})
+}
+
+// ShuffleField shuffles the tokens of the field with the next tick
+func (g *Game) ShuffleField() {
+	g.QueueInput(&Input{
+		Type: InputTypeShuffleField,
+	})
+}
+
+func (g *Game) overridePiece(piece *piece.Piece) {
+	g.nextPieces.SetOverride(piece)
+
+	if piece != nil && g.fallingPiece != nil {
+		g.fallingPiece.SetPiece(piece, g.getSpawnX(), 0, 0)
 	}
 }
 
 func (g *Game) init(seed int64) {
-	g.pieceGenerator = piece.NewGenerator(seed)
+	pieceSeed := seed
+
+	if g.shared != nil {
+		pieceSeed = g.shared.getPieceSeed(g.id, seed)
+	}
+
+	g.pieceGenerator = g.newPieceGenerator(pieceSeed)
 
 	g.fallingPiece = nil
-	g.nextPiece = nil
 	g.holdingPiece = piece.NewLivingPiece(nil)
 	g.over = true
-	g.lastUpdate = nil
-
-	g.field.Reset()
-	g.score.Reset()
+	g.tick = 0
+	g.inputLog = nil
+	g.checkpoints = nil
+	g.stateHashes = nil
+	g.takePendingInputs()
+	g.lastAttackerId = ""
+	g.piecesPlaced = 0
+	g.completed = false
+	g.commandTime = 0
+	g.finishTime = 0
+
+	// the state of a co-op group is reset once by the group, not by every member
+	if g.shared == nil {
+		g.field.SetSeed(seed)
+		g.field.Reset()
+		g.score.Reset()
+		g.incoming.Reset()
+		g.attackChain.Reset()
+	}
 
-	g.pieceGenerator.NextBag()
+	g.nextPieces = piece.NewQueue(g.pieceGenerator, g.config.PreviewCount)
 
 	g.lastActivity = time.Now()
 }
@@ -182,9 +367,17 @@ func (g *Game) ToPayload() *Payload {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
+	return g.toPayload()
+}
+
+func (g *Game) toPayload() *Payload {
 	return &Payload{
 		Id:         g.id,
 		PlayerName: g.player.GetName(),
+		Connected:  g.con != nil,
+		Host:       g.player.IsHost(),
+		Ready:      g.player.IsReady(),
+		Team:       g.player.GetTeam(),
 	}
 }
 
@@ -195,31 +388,36 @@ func (g *Game) GetId() string {
 	return g.id
 }
 
-func (g *Game) doUpdate(delta int64) {
-	oldBedrockLevel := g.field.GetCurrentBedrock()
-	clearedLines, gameOver := g.updateFallingPiece(delta)
+// doUpdate runs the simulation for the current tick and publishes what changed
+func (g *Game) doUpdate() {
+	lineClear, gameOver := g.updateFallingPiece(1)
 
-	if clearedLines > 0 {
-		g.score.AddLines(clearedLines)
+	if lineClear != nil && (lineClear.Lines > 0 || lineClear.Spin != attack.SpinNone) {
+		g.score.AddClear(lineClear)
 
-		newBedrockLevel := g.field.GetCurrentBedrock()
-
-		if newBedrockLevel == 0 && g.bedrockChannel != nil {
-			distributableBedrock := int(math.Max(float64(clearedLines-oldBedrockLevel), 0))
+		g.bus.Publish(&event.Event{
+			Type:    event.TypeLineClear,
+			Origin:  event.OriginGame(g.id),
+			Payload: lineClear,
+		})
+	}
 
-			g.bedrockChannel <- &Bedrock{
-				Amount:   distributableBedrock,
-				SourceId: g.id,
-			}
-		}
+	if !gameOver && g.isGoalReached() {
+		gameOver = true
 	}
 
+	g.updateGhostPiece()
+
 	if g.field.Dirty.Clear() {
-		g.bus.Publish(&event.Event{
-			Type:    event.TypeFieldUpdate,
-			Origin:  event.OriginGame(g.id),
-			Payload: g.field.ToPayload(),
-		})
+		fieldPayload := g.field.ToPayload()
+
+		for _, ownerId := range g.getStateOwnerIds() {
+			g.bus.Publish(&event.Event{
+				Type:    event.TypeFieldUpdate,
+				Origin:  event.OriginGame(ownerId),
+				Payload: fieldPayload,
+			})
+		}
 	}
 
 	if g.fallingPiece.Dirty.Clear() {
@@ -230,11 +428,19 @@ func (g *Game) doUpdate(delta int64) {
 		})
 	}
 
-	if g.nextPiece.Dirty.Clear() {
+	if g.nextPieces.Dirty.Clear() {
 		g.bus.Publish(&event.Event{
-			Type:    event.TypeNextPieceUpdate,
+			Type:   event.TypeNextPieceUpdate,
+			Origin: event.OriginGame(g.id),
+			Payload: &piece.Payload{
+				Token: g.nextPieces.Peek().Token,
+			},
+		})
+
+		g.bus.Publish(&event.Event{
+			Type:    event.TypeNextQueueUpdate,
 			Origin:  event.OriginGame(g.id),
-			Payload: g.nextPiece.ToPayload(),
+			Payload: g.nextPieces.ToPayload(),
 		})
 	}
 
@@ -246,48 +452,84 @@ func (g *Game) doUpdate(delta int64) {
 		})
 	}
 
+	if g.incoming.Dirty.Clear() {
+		incomingPayload := g.incoming.ToPayload()
+
+		for _, ownerId := range g.getStateOwnerIds() {
+			g.bus.Publish(&event.Event{
+				Type:    event.TypeIncomingAttackUpdate,
+				Origin:  event.OriginGame(ownerId),
+				Payload: incomingPayload,
+			})
+		}
+	}
+
 	if g.score.Dirty.Clear() {
-		g.bus.Publish(&event.Event{
-			Type:    event.TypeScoreUpdate,
-			Origin:  event.OriginGame(g.id),
-			Payload: g.score.ToPayload(),
-		})
+		scorePayload := g.score.ToPayload()
+
+		for _, ownerId := range g.getStateOwnerIds() {
+			g.bus.Publish(&event.Event{
+				Type:    event.TypeScoreUpdate,
+				Origin:  event.OriginGame(ownerId),
+				Payload: scorePayload,
+			})
+		}
 	}
 
 	if gameOver {
-		g.bus.Publish(&event.Event{
-			Type:   event.TypeGameOver,
-			Origin: event.OriginGame(g.id),
-		})
+		for _, ownerId := range g.getStateOwnerIds() {
+			g.bus.Publish(&event.Event{
+				Type:   event.TypeGameOver,
+				Origin: event.OriginGame(ownerId),
+			})
+		}
 
-		go g.ToggleOver(false)
+		if g.shared != nil {
+			g.shared.setOver(g)
+		} else {
+			g.setOver(false)
+		}
 	}
 }
 
-func (g *Game) Update() {
-	if g.IsOver() {
-		return
-	}
-
+// Start resets the game, it begins to update at startAt so multiple games can start in sync.
+// Members of a co-op group are started with their group.
+func (g *Game) Start(seed int64, startAt time.Time) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	now := time.Now().UnixMilli()
+	g.start(seed, startAt)
+}
 
-	if g.lastUpdate != nil {
-		g.doUpdate(now - *g.lastUpdate)
-	}
+func (g *Game) start(seed int64, startAt time.Time) {
+	g.init(seed)
 
-	g.lastUpdate = &now
+	g.startAt = startAt
+
+	g.over = false
 }
 
-func (g *Game) Start(seed int64) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// GetLastAttackerId returns the id of the game whose garbage was the last to land in this game
+func (g *Game) GetLastAttackerId() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
-	g.init(seed)
+	return g.lastAttackerId
+}
 
-	g.over = false
+// GetSurvivalTime returns how long the game has been running, until it was over
+func (g *Game) GetSurvivalTime() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return ticksToDuration(g.tick)
+}
+
+func (g *Game) GetStartAt() time.Time {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.startAt
 }
 
 func (g *Game) GetLastActivity() time.Time {
@@ -302,8 +544,12 @@ func (g *Game) ToggleOver(shutdown bool) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if g.over != true && !shutdown {
-		go g.overCallback()
+	g.setOver(shutdown)
+}
+
+func (g *Game) setOver(shutdown bool) {
+	if g.over != true && !shutdown && g.overCallback != nil {
+		go g.overCallback(g)
 	}
 
 	g.over = true
@@ -325,7 +571,7 @@ func (g *Game) startUpdater() {
 		select {
 		case <-g.ctx.Done():
 			return
-		case <-time.After(time.Millisecond * 10): // 100 fps
+		case <-time.After(TickDuration):
 			g.Update()
 			break
 		}
@@ -337,21 +583,31 @@ func (g *Game) startCommandReader() {
 	defer g.wg.Done()
 
 	for {
+		con := g.GetConnection()
+
+		if con == nil {
+			select {
+			case <-g.ctx.Done():
+				return
+			case <-time.After(time.Millisecond * 250):
+				continue
+			}
+		}
+
 		select {
 		case <-g.ctx.Done():
 			return
-		case cmd := <-g.con.GetInputChannel():
-			g.HandleCommand(Command(cmd))
+		case cmd := <-con.GetInputChannel():
+			if isRoomCommand(cmd) {
+				if g.roomCommandCallback != nil {
+					g.roomCommandCallback(g, cmd)
+				}
+			} else {
+				g.HandleCommand(Command(cmd))
+			}
 			break
 		case <-time.After(time.Millisecond * 250):
 			break
 		}
 	}
 }
-
-func (g *Game) AddBedrock(amount int) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
-
-	g.field.IncreaseBedrock(amount)
-}
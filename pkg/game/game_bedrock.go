@@ -1,6 +1,55 @@
 package game
 
+import (
+	"math"
+)
+
+const GarbageModeBedrock = "bedrock"
+const GarbageModeGarbage = "garbage"
+
 type Bedrock struct {
 	Amount   int
 	SourceId string
 }
+
+func (g *Game) addBedrock(amount int) {
+	if g.config.GarbageMode == GarbageModeGarbage {
+		g.field.AddGarbage(amount)
+	} else {
+		g.field.IncreaseBedrock(amount)
+	}
+}
+
+// ReceiveBedrock queues bedrock sent by another game, it materializes when this game locks a piece without clearing lines
+func (g *Game) ReceiveBedrock(b *Bedrock) {
+	g.QueueInput(&Input{
+		Type:     InputTypeGarbage,
+		Amount:   b.Amount,
+		SourceId: b.SourceId,
+	})
+}
+
+// sendAttack cancels incoming bedrock with the attack and sends what is left to the room
+func (g *Game) sendAttack(lines int, oldBedrockLevel int) {
+	if g.field.GetCurrentBedrock() != 0 {
+		return
+	}
+
+	amount := g.incoming.Cancel(int(math.Max(float64(lines-oldBedrockLevel), 0)))
+
+	// headless games cancel like any other game, they just have nobody to send the rest to
+	if amount > 0 && g.bedrockChannel != nil {
+		g.bedrockChannel <- &Bedrock{
+			Amount:   amount,
+			SourceId: g.id,
+		}
+	}
+}
+
+func (g *Game) applyIncoming() {
+	for _, b := range g.incoming.TakeReady(g.tick) {
+		g.addBedrock(b.Amount)
+
+		g.lastAttackerId = b.SourceId
+	}
+}
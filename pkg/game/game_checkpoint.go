@@ -0,0 +1,105 @@
+package game
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Checkpoint is the state of a game after a tick, games take one whenever a piece locks
+type Checkpoint struct {
+	Tick  int64  `json:"tick"`
+	Field string `json:"field"`
+	Score int    `json:"score"`
+	Lines int    `json:"lines"`
+	Level int    `json:"level"`
+}
+
+func (g *Game) toCheckpoint() *Checkpoint {
+	return &Checkpoint{
+		Tick:  g.tick,
+		Field: g.field.ToPayload().Data,
+		Score: g.score.GetScore(),
+		Lines: g.score.GetLines(),
+		Level: g.score.GetLevel(),
+	}
+}
+
+// GetCheckpoint returns the current state of the game
+func (g *Game) GetCheckpoint() *Checkpoint {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.toCheckpoint()
+}
+
+// GetCheckpoints returns the checkpoints taken since the start, in the order they were taken
+func (g *Game) GetCheckpoints() []*Checkpoint {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	checkpoints := make([]*Checkpoint, len(g.checkpoints))
+	copy(checkpoints, g.checkpoints)
+
+	return checkpoints
+}
+
+// StateHash is the hash of the state of a game from the tick on, games record one whenever the hash changes.
+// Checkpoints tell the state at a lock, the hashes tell the exact tick two runs of a game went apart.
+type StateHash struct {
+	Tick int64  `json:"tick"`
+	Hash uint32 `json:"hash"`
+}
+
+// getStateHash hashes everything a tick can change: the field, the falling and holding piece, the score and the incoming garbage
+func (g *Game) getStateHash() uint32 {
+	h := fnv.New32a()
+
+	g.field.WriteHash(h)
+
+	values := []int64{
+		int64(g.score.GetScore()),
+		int64(g.score.GetLines()),
+		int64(g.score.GetLevel()),
+		int64(g.incoming.ToPayload().Amount),
+	}
+
+	if g.fallingPiece != nil {
+		p, r, x, y := g.fallingPiece.GetPieceAndPosition()
+
+		if p != nil {
+			values = append(values, int64(p.Token), int64(r), int64(x), int64(y))
+		}
+	}
+
+	if hp := g.holdingPiece.GetPiece(); hp != nil {
+		values = append(values, int64(hp.Token))
+	}
+
+	_ = binary.Write(h, binary.BigEndian, values)
+
+	return h.Sum32()
+}
+
+func (g *Game) recordStateHash() {
+	hash := g.getStateHash()
+
+	if n := len(g.stateHashes); n != 0 && g.stateHashes[n-1].Hash == hash {
+		return
+	}
+
+	g.stateHashes = append(g.stateHashes, &StateHash{
+		Tick: g.tick,
+		Hash: hash,
+	})
+}
+
+// GetStateHashes returns the state hashes recorded since the start, one for every tick the state changed at
+func (g *Game) GetStateHashes() []*StateHash {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	stateHashes := make([]*StateHash, len(g.stateHashes))
+	copy(stateHashes, g.stateHashes)
+
+	return stateHashes
+}
@@ -1,6 +1,9 @@
 package game
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Command string
 
@@ -8,29 +11,51 @@ const CommandLeft = Command("L")
 const CommandRight = Command("R")
 const CommandDown = Command("D")
 const CommandRotate = Command("X")
+const CommandRotateCounterClockwise = Command("Z")
+const CommandRotate180 = Command("A")
 const CommandHardLock = Command("P")
 const CommandHold = Command("H")
 const CommandItem = Command("I")
 
+// isRoomCommand tells json encoded room commands apart from the single letter game commands
+func isRoomCommand(cmd string) bool {
+	return strings.HasPrefix(cmd, "{")
+}
+
+// HandleCommand queues a command of the player, it is applied with the next tick
 func (g *Game) HandleCommand(cmd Command) {
-	if g.IsOver() {
+	if g.IsOver() || time.Now().Before(g.GetStartAt()) {
 		return
 	}
 
 	g.lastActivity = time.Now()
 
+	g.QueueInput(&Input{
+		Type:    InputTypeCommand,
+		Command: cmd,
+		Time:    time.Since(g.GetStartAt()).Milliseconds(),
+	})
+}
+
+func (g *Game) handleCommand(cmd Command) {
 	switch cmd {
 	case CommandLeft:
-		g.tryTranslateFallingPiece(0, -1, 0)
+		g.tryTranslateFallingPiece(-1, 0)
 		break
 	case CommandRight:
-		g.tryTranslateFallingPiece(0, 1, 0)
+		g.tryTranslateFallingPiece(1, 0)
 		break
 	case CommandDown:
-		g.tryTranslateFallingPiece(0, 0, 1)
+		g.tryTranslateFallingPiece(0, 1)
 		break
 	case CommandRotate:
-		g.tryTranslateFallingPiece(1, 0, 0)
+		g.tryRotateFallingPiece(1)
+		break
+	case CommandRotateCounterClockwise:
+		g.tryRotateFallingPiece(-1)
+		break
+	case CommandRotate180:
+		g.tryRotateFallingPiece(2)
 		break
 	case CommandHardLock:
 		g.hardLockFallingPiece()
@@ -39,7 +64,10 @@ func (g *Game) HandleCommand(cmd Command) {
 		g.tryHoldFallingPiece()
 		break
 	case CommandItem:
-		g.activateItem()
+		// items are handled by the room, headless games have none
+		if g.activateItemCallback != nil {
+			go g.activateItemCallback(g)
+		}
 		break
 	default:
 		break
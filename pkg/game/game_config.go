@@ -0,0 +1,54 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/gravity"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"github.com/nitwhiz/quadis-server/pkg/rotation"
+)
+
+// Config holds the rules a game is played by
+type Config struct {
+	FieldWidth     int
+	FieldHeight    int
+	GravityCurve   string
+	GravityTable   []float64
+	StartLevel     int
+	RotationSystem string
+	// LockDelay is the time in ms a grounded piece waits before locking
+	LockDelay  int
+	LockResets int
+	// PreviewCount is the number of upcoming pieces shown to the player
+	PreviewCount int
+	Randomizer   string
+	// RandomizerSequence is the fixed sequence of piece letters used by the sequence randomizer
+	RandomizerSequence string
+	GarbageMode        string
+	GarbageHoles       int
+	GarbageMessiness   float64
+	// GarbageDelay is the time in ms received bedrock waits before it can materialize
+	GarbageDelay int
+	// Mode is the game mode, it decides when a game is finished besides topping out
+	Mode string
+}
+
+// DefaultConfig returns the config of a game with the default rules of a room.
+// As before lock delays and gravity curves existed, pieces fall one cell per second and lock as soon as they are grounded,
+// received bedrock comes with the next lock. Rooms opt into the rest.
+func DefaultConfig() *Config {
+	return &Config{
+		FieldWidth:       10,
+		FieldHeight:      20,
+		GravityCurve:     gravity.CurveConstant,
+		StartLevel:       1,
+		RotationSystem:   rotation.SystemSRS,
+		LockDelay:        0,
+		LockResets:       15,
+		PreviewCount:     5,
+		Randomizer:       piece.Randomizer7Bag,
+		GarbageMode:      GarbageModeBedrock,
+		GarbageHoles:     1,
+		GarbageMessiness: .3,
+		GarbageDelay:     0,
+		Mode:             ModeVersus,
+	}
+}
@@ -1,42 +1,71 @@
 package game
 
 import (
+	"github.com/nitwhiz/quadis-server/pkg/attack"
 	"github.com/nitwhiz/quadis-server/pkg/falling_piece"
 	"github.com/nitwhiz/quadis-server/pkg/metrics"
 	"github.com/nitwhiz/quadis-server/pkg/piece"
 )
 
-func (g *Game) putFallingPiece() int {
+func (g *Game) putFallingPiece() *attack.Clear {
 	g.fallingPiece.Lock()
 
 	p, pRot, pX, pY := g.fallingPiece.GetPieceAndPosition()
 
+	spin := attack.DetectTSpin(p, pRot, pX, pY, g.fallingPiece.GetLastKick(), g.field.IsOccupied)
+
 	g.field.PutPiece(p, pRot, pX, pY)
 
-	return g.field.ClearLines()
+	g.piecesPlaced += 1
+
+	clearedLines := g.field.ClearLines()
+
+	return g.attackChain.Next(clearedLines, spin, clearedLines > 0 && g.field.IsEmpty())
 }
 
-func (g *Game) hardLockFallingPiece() {
-	if g.fallingPiece == nil {
+// getLandingY returns the y the piece would be locked at when hard locked from y
+func (g *Game) getLandingY(p *piece.Piece, r piece.Rotation, x int, y int) int {
+	dy := 0
+
+	for dy < g.field.GetHeight() {
+		if !g.canPutPiece(p, r, x, y+dy) {
+			break
+		}
+
+		dy++
+	}
+
+	return y + dy - 1
+}
+
+// updateGhostPiece updates the landing position of the falling piece, it has to be called after the field or the piece changed
+func (g *Game) updateGhostPiece() {
+	if g.fallingPiece == nil || !g.fallingPiece.TryLockMovement() {
 		return
 	}
 
-	g.fallingPiece.LockMovement()
 	defer g.fallingPiece.UnlockMovement()
 
-	dy := 0
-
 	p, pRot, pX, pY := g.fallingPiece.GetPieceAndPosition()
 
-	for dy < g.GetField().GetHeight() {
-		if !g.field.CanPutPiece(p, pRot, pX, pY+dy) {
-			break
-		}
+	if p == nil {
+		return
+	}
 
-		dy++
+	g.fallingPiece.SetGhostY(g.getLandingY(p, pRot, pX, pY))
+}
+
+func (g *Game) hardLockFallingPiece() {
+	if g.fallingPiece == nil {
+		return
 	}
 
-	g.fallingPiece.SetY(pY + dy - 1)
+	g.fallingPiece.LockMovement()
+	defer g.fallingPiece.UnlockMovement()
+
+	p, pRot, pX, pY := g.fallingPiece.GetPieceAndPosition()
+
+	g.fallingPiece.SetY(g.getLandingY(p, pRot, pX, pY))
 	g.fallingPiece.Lock()
 
 	fP := g.fallingPiece.GetPiece()
@@ -47,28 +76,24 @@ func (g *Game) hardLockFallingPiece() {
 }
 
 func (g *Game) nextFallingPiece(lastPieceWasHeld bool) {
-	if g.nextPiece == nil {
-		g.nextPiece = piece.NewLivingPiece(g.pieceGenerator.NextElement())
-	}
-
 	if g.fallingPiece == nil {
 		g.fallingPiece = falling_piece.New(nil)
-	}
+		g.fallingPiece.SetLockDelay(msToTicks(g.config.LockDelay), g.config.LockResets)
 
-	g.fallingPiece.SetPiece(g.nextPiece.GetPiece(), g.field.GetCenterX(), 0, 0)
-
-	if g.overridePiece == nil {
-		g.nextPiece.SetPiece(g.pieceGenerator.NextElement())
-	} else {
-		g.nextPiece.SetPiece(g.overridePiece)
+		if g.shared != nil {
+			g.shared.setFallingPiece(g.id, g.fallingPiece)
+		}
 	}
 
+	g.fallingPiece.SetGravity(g.gravityCurve.GetGravity(g.score.GetLevel()))
+	g.fallingPiece.SetPiece(g.nextPieces.Next(), g.getSpawnX(), 0, 0)
+
 	if !lastPieceWasHeld {
 		g.holdingPiece.SetLocked(false)
 	}
 }
 
-func (g *Game) tryTranslateFallingPiece(dr piece.Rotation, dx int, dy int) {
+func (g *Game) tryTranslateFallingPiece(dx int, dy int) {
 	g.fallingPiece.LockMovement()
 	defer g.fallingPiece.UnlockMovement()
 
@@ -78,50 +103,78 @@ func (g *Game) tryTranslateFallingPiece(dr piece.Rotation, dx int, dy int) {
 
 	p, pr, px, py := g.fallingPiece.GetPieceAndPosition()
 
-	if dr != 0 && g.fallingPiece.IsRotationLocked() {
-		dr = 0
+	if g.canPutPiece(p, pr, px+dx, py+dy) {
+		g.fallingPiece.SetPosition(pr, px+dx, py+dy)
+		g.fallingPiece.ResetLockTimer()
+
+		metrics.IncreasePieceMovementsTotal(p.Token, 0, dx, dy)
+	}
+}
+
+// tryRotateFallingPiece rotates clockwise by dr quarter turns, trying the kicks of the rotation system in order
+func (g *Game) tryRotateFallingPiece(dr piece.Rotation) {
+	g.fallingPiece.LockMovement()
+	defer g.fallingPiece.UnlockMovement()
+
+	if g.fallingPiece == nil || g.fallingPiece.IsLocked() || g.fallingPiece.IsRotationLocked() {
+		return
 	}
 
+	p, pr, px, py := g.fallingPiece.GetPieceAndPosition()
+
+	fr := p.ClampRotation(pr)
 	tr := p.ClampRotation(pr + dr)
 
-	if g.field.CanPutPiece(p, tr, px+dx, py+dy) {
-		g.fallingPiece.SetPosition(tr, px+dx, py+dy)
+	for i, kick := range g.rotationSystem.GetKicks(p, fr, tr) {
+		if g.canPutPiece(p, tr, px+kick.X, py+kick.Y) {
+			g.fallingPiece.SetRotatedPosition(tr, px+kick.X, py+kick.Y, i)
+			g.fallingPiece.ResetLockTimer()
 
-		fP := g.fallingPiece.GetPiece()
+			metrics.IncreasePieceMovementsTotal(p.Token, dr, kick.X, kick.Y)
 
-		if fP != nil {
-			metrics.IncreasePieceMovementsTotal(g.fallingPiece.GetPiece().Token, dr, dx, dy)
+			return
 		}
 	}
 }
 
-func (g *Game) clearLinesAndNextPiece() (int, bool) {
-	clearedLines := g.putFallingPiece()
+func (g *Game) clearLinesAndNextPiece() (*attack.Clear, bool) {
+	oldBedrockLevel := g.field.GetCurrentBedrock()
+	lineClear := g.putFallingPiece()
 	gameOver := false
 
+	if lineClear.Lines > 0 {
+		g.sendAttack(lineClear.Attack, oldBedrockLevel)
+	} else {
+		g.applyIncoming()
+	}
+
+	g.checkpoints = append(g.checkpoints, g.toCheckpoint())
+
 	g.nextFallingPiece(false)
 
 	p, fpRot, fpX, fpY := g.fallingPiece.GetPieceAndPosition()
 
-	if !g.field.CanPutPiece(p, fpRot, fpX, fpY) {
+	// in a co-op group spawning into the piece of another member tops out as well
+	if !g.canPutPiece(p, fpRot, fpX, fpY) {
 		gameOver = true
 	}
 
-	return clearedLines, gameOver
+	return lineClear, gameOver
 }
 
-func (g *Game) updateFallingPiece(delta int64) (int, bool) {
+// updateFallingPiece moves the falling piece by gravity for the ticks and locks it, returns the clear if a piece was locked
+func (g *Game) updateFallingPiece(ticks int64) (*attack.Clear, bool) {
 	if g.fallingPiece == nil {
 		g.nextFallingPiece(false)
 	}
 
 	if !g.fallingPiece.TryLockMovement() {
-		return 0, false
+		return nil, false
 	}
 
 	defer g.fallingPiece.UnlockMovement()
 
-	clearedLines := 0
+	var lineClear *attack.Clear
 	gameOver := false
 
 	p, pRot, pX, pY := g.fallingPiece.GetPieceAndPosition()
@@ -141,24 +194,40 @@ func (g *Game) updateFallingPiece(delta int64) (int, bool) {
 
 	if forcedUp {
 		g.fallingPiece.SetY(pY)
-		g.fallingPiece.Lock()
+
+		// in a co-op group the field moves under the piece when another member locks, the piece keeps falling if it still fits
+		if g.shared == nil || !g.field.CanPutPiece(p, pRot, pX, pY) {
+			g.fallingPiece.Lock()
+		}
 	}
 
 	if g.fallingPiece.IsLocked() {
-		clearedLines, gameOver = g.clearLinesAndNextPiece()
+		return g.clearLinesAndNextPiece()
+	}
+
+	grounded := !g.field.CanPutPiece(p, pRot, pX, pY+1)
+
+	g.fallingPiece.SetGrounded(grounded)
+
+	if grounded {
+		if g.fallingPiece.UpdateLockTimer(ticks) {
+			lineClear, gameOver = g.clearLinesAndNextPiece()
+		}
 	} else {
-		shouldMove, nextY := g.fallingPiece.GetNextPosition(delta)
-
-		if shouldMove {
-			if g.field.CanPutPiece(p, pRot, pX, nextY) {
-				g.fallingPiece.SetY(nextY)
-			} else {
-				clearedLines, gameOver = g.clearLinesAndNextPiece()
-			}
+		distance := g.fallingPiece.GetFallDistance(ticks)
+		nextY := pY
+
+		// in a co-op group the piece waits above the pieces of the other members instead of locking on them
+		for nextY < pY+distance && g.canPutPiece(p, pRot, pX, nextY+1) {
+			nextY++
+		}
+
+		if nextY != pY {
+			g.fallingPiece.SetY(nextY)
 		}
 	}
 
-	return clearedLines, gameOver
+	return lineClear, gameOver
 }
 
 func (g *Game) tryHoldFallingPiece() {
@@ -176,7 +245,7 @@ func (g *Game) tryHoldFallingPiece() {
 	if currentHoldingPiece == nil {
 		g.nextFallingPiece(true)
 	} else {
-		g.fallingPiece.SetPiece(currentHoldingPiece, g.field.GetCenterX(), 0, 0)
+		g.fallingPiece.SetPiece(currentHoldingPiece, g.getSpawnX(), 0, 0)
 	}
 
 	g.holdingPiece.SetLocked(true)
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/gravity"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+	"time"
+)
+
+// newLockGame starts a game of O pieces dropping at 20G, so the first piece lands within the first tick
+func newLockGame(t *testing.T, lockDelay int, lockResets int) *Game {
+	g := newHeadlessGame(t, "lock")
+
+	config := *g.config
+	config.GravityCurve = gravity.CurveConstant
+	config.GravityTable = []float64{gravity.MaxGravity}
+	config.Randomizer = piece.RandomizerSequence
+	config.RandomizerSequence = "O"
+	config.LockDelay = lockDelay
+	config.LockResets = lockResets
+
+	if err := g.configure(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Start(1, time.Now())
+
+	return g
+}
+
+func TestUpdateFallingPieceLockDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		lockDelay  int
+		lockResets int
+		// commands are queued from the second tick on, the first one the piece is grounded at
+		commands []Command
+		lockTick int64
+	}{
+		{
+			name:      "no lock delay locks on the first grounded tick",
+			lockDelay: 0,
+			lockTick:  2,
+		},
+		{
+			name:       "the piece locks when the delay runs out",
+			lockDelay:  100,
+			lockResets: 15,
+			lockTick:   7,
+		},
+		{
+			name:       "moves reset the delay until the resets run out",
+			lockDelay:  100,
+			lockResets: 3,
+			commands:   []Command{CommandLeft, CommandRight, CommandLeft, CommandRight, CommandLeft, CommandRight, CommandLeft},
+			lockTick:   10,
+		},
+		{
+			name:       "rotations reset the delay until the resets run out",
+			lockDelay:  100,
+			lockResets: 3,
+			commands:   []Command{CommandRotate, CommandRotate, CommandRotate, CommandRotate, CommandRotate, CommandRotate, CommandRotate},
+			lockTick:   10,
+		},
+		{
+			name:       "moves do not reset the delay without resets",
+			lockDelay:  100,
+			lockResets: 0,
+			commands:   []Command{CommandLeft, CommandRight, CommandLeft, CommandRight, CommandLeft, CommandRight},
+			lockTick:   7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newLockGame(t, tt.lockDelay, tt.lockResets)
+
+			for len(g.GetCheckpoints()) == 0 && g.GetTick() < 100 {
+				if i := int(g.GetTick()) - 1; i >= 0 && i < len(tt.commands) {
+					g.QueueInput(&Input{Type: InputTypeCommand, Command: tt.commands[i]})
+				}
+
+				g.Step()
+			}
+
+			if g.GetTick() != tt.lockTick {
+				t.Fatalf("expected the piece to lock at tick %d, locked at %d", tt.lockTick, g.GetTick())
+			}
+		})
+	}
+}
+
+func TestUpdateFallingPieceNewLowestYRestoresResets(t *testing.T) {
+	g := newLockGame(t, 100, 1)
+
+	// a ledge below the spawn, the piece lands on it and falls further when moved off it
+	g.GetField().PutPiece(&piece.O, 0, g.getSpawnX(), g.GetField().GetHeight()-2)
+
+	g.Step()
+	g.Step()
+
+	_, _, _, ledgeY := g.GetFallingPiece().GetPieceAndPosition()
+
+	// rotating the o piece in place uses up the reset on the ledge
+	g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandRotate})
+	g.Step()
+
+	if left := g.GetFallingPiece().ToPayload().LockResetsLeft; left != 0 {
+		t.Fatalf("expected the reset to be used up on the ledge, %d left", left)
+	}
+
+	for _, cmd := range []Command{CommandRight, CommandRight} {
+		g.QueueInput(&Input{Type: InputTypeCommand, Command: cmd})
+		g.Step()
+	}
+
+	payload := g.GetFallingPiece().ToPayload()
+
+	if payload.Y <= ledgeY || payload.LockResetsLeft != 1 || len(g.GetCheckpoints()) != 0 {
+		t.Fatalf("expected the piece to fall below the ledge at %d with its reset restored, got %+v", ledgeY, payload)
+	}
+}
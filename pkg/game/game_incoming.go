@@ -0,0 +1,129 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/dirty"
+	"sync"
+)
+
+type incomingAttack struct {
+	bedrock *Bedrock
+	readyAt int64
+}
+
+// Incoming queues received attacks until they materialize in the field
+type Incoming struct {
+	attacks []*incomingAttack
+	// delay is the number of ticks an attack waits before it is ready
+	delay int64
+	Dirty *dirty.Dirtiness
+	mu    *sync.RWMutex
+}
+
+type IncomingAttackPayload struct {
+	Amount      int    `json:"amount"`
+	SourceId    string `json:"sourceId"`
+	ReadyAtTick int64  `json:"readyAtTick"`
+}
+
+type IncomingPayload struct {
+	Amount  int                      `json:"amount"`
+	Attacks []*IncomingAttackPayload `json:"attacks"`
+}
+
+func NewIncoming(delay int64) *Incoming {
+	return &Incoming{
+		attacks: []*incomingAttack{},
+		delay:   delay,
+		Dirty:   dirty.New(),
+		mu:      &sync.RWMutex{},
+	}
+}
+
+func (i *Incoming) ToPayload() *IncomingPayload {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	p := IncomingPayload{
+		Amount:  0,
+		Attacks: []*IncomingAttackPayload{},
+	}
+
+	for _, a := range i.attacks {
+		p.Amount += a.bedrock.Amount
+
+		p.Attacks = append(p.Attacks, &IncomingAttackPayload{
+			Amount:      a.bedrock.Amount,
+			SourceId:    a.bedrock.SourceId,
+			ReadyAtTick: a.readyAt,
+		})
+	}
+
+	return &p
+}
+
+func (i *Incoming) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.attacks = []*incomingAttack{}
+
+	i.Dirty.Trip()
+}
+
+// Add queues an attack received at the tick
+func (i *Incoming) Add(b *Bedrock, tick int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if b.Amount <= 0 {
+		return
+	}
+
+	i.attacks = append(i.attacks, &incomingAttack{
+		bedrock: b,
+		readyAt: tick + i.delay,
+	})
+
+	i.Dirty.Trip()
+}
+
+// Cancel removes up to lines pending lines, oldest first, and returns the lines left over
+func (i *Incoming) Cancel(lines int) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for lines > 0 && len(i.attacks) > 0 {
+		a := i.attacks[0]
+
+		if a.bedrock.Amount > lines {
+			a.bedrock.Amount -= lines
+			lines = 0
+		} else {
+			lines -= a.bedrock.Amount
+			i.attacks = i.attacks[1:]
+		}
+
+		i.Dirty.Trip()
+	}
+
+	return lines
+}
+
+// TakeReady removes and returns all attacks whose delay has passed at the tick
+func (i *Incoming) TakeReady(tick int64) []*Bedrock {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var ready []*Bedrock
+
+	for len(i.attacks) > 0 && i.attacks[0].readyAt <= tick {
+		ready = append(ready, i.attacks[0].bedrock)
+		i.attacks = i.attacks[1:]
+	}
+
+	if len(ready) > 0 {
+		i.Dirty.Trip()
+	}
+
+	return ready
+}
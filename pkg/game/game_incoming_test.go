@@ -0,0 +1,148 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+	"time"
+)
+
+func TestIncomingQueue(t *testing.T) {
+	i := NewIncoming(10)
+
+	i.Add(&Bedrock{Amount: 3, SourceId: "a"}, 0)
+	i.Add(&Bedrock{Amount: 2, SourceId: "b"}, 5)
+
+	if amount := i.ToPayload().Amount; amount != 5 {
+		t.Fatalf("expected 5 pending lines, got %d", amount)
+	}
+
+	// the oldest attack is cancelled first
+	if left := i.Cancel(4); left != 0 {
+		t.Fatalf("expected the clear to be used up, %d lines left", left)
+	}
+
+	if p := i.ToPayload(); p.Amount != 1 || len(p.Attacks) != 1 || p.Attacks[0].SourceId != "b" {
+		t.Fatalf("expected 1 line of b to be left, got %+v", p)
+	}
+
+	if ready := i.TakeReady(14); len(ready) != 0 {
+		t.Fatalf("expected nothing to be ready before tick 15, got %d attacks", len(ready))
+	}
+
+	if ready := i.TakeReady(15); len(ready) != 1 || ready[0].Amount != 1 {
+		t.Fatalf("expected the attack of b to be ready at tick 15, got %+v", ready)
+	}
+
+	if left := i.Cancel(3); left != 3 {
+		t.Fatalf("expected an empty queue to leave the clear as it is, got %d", left)
+	}
+}
+
+// newGarbageGame starts a game of O pieces which receives bedrock after the delay
+func newGarbageGame(t *testing.T, garbageDelay int) *Game {
+	g := newHeadlessGame(t, "garbage")
+
+	config := *g.config
+	config.Randomizer = piece.RandomizerSequence
+	config.RandomizerSequence = "O"
+	config.GarbageDelay = garbageDelay
+
+	if err := g.configure(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Start(1, time.Now())
+	g.Step()
+
+	return g
+}
+
+// dropLeft moves the falling O piece to the left wall and hard locks it
+func dropLeft(g *Game) {
+	for i := 0; i < g.GetField().GetWidth(); i++ {
+		g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandLeft})
+	}
+
+	g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+	g.Step()
+}
+
+func TestIncomingAppliesOnLockWithoutClear(t *testing.T) {
+	g := newGarbageGame(t, 0)
+
+	g.QueueInput(&Input{Type: InputTypeGarbage, Amount: 2, SourceId: "other"})
+	g.Step()
+
+	if bedrock := g.GetField().GetCurrentBedrock(); bedrock != 0 {
+		t.Fatalf("expected the bedrock to wait for a lock, got %d", bedrock)
+	}
+
+	dropLeft(g)
+
+	if bedrock := g.GetField().GetCurrentBedrock(); bedrock != 2 {
+		t.Fatalf("expected 2 bedrock after the lock, got %d", bedrock)
+	}
+
+	if amount := g.incoming.ToPayload().Amount; amount != 0 {
+		t.Fatalf("expected no pending lines, got %d", amount)
+	}
+}
+
+func TestIncomingIsCancelledByClear(t *testing.T) {
+	g := newGarbageGame(t, 0)
+
+	f := g.GetField()
+	h := f.GetHeight()
+
+	// the bottom two rows are filled except for the two columns at the left wall, with one more O on top to avoid a perfect clear
+	for x := 1; x < f.GetWidth()-1; x += 2 {
+		f.PutPiece(&piece.O, 0, x, h-2)
+	}
+
+	f.PutPiece(&piece.O, 0, f.GetWidth()-3, h-4)
+
+	g.QueueInput(&Input{Type: InputTypeGarbage, Amount: 3, SourceId: "other"})
+	g.Step()
+
+	dropLeft(g)
+
+	// a double sends one line, it cancels one of the pending lines instead
+	if amount := g.incoming.ToPayload().Amount; amount != 2 {
+		t.Fatalf("expected 2 pending lines after the double, got %d", amount)
+	}
+
+	if bedrock := f.GetCurrentBedrock(); bedrock != 0 {
+		t.Fatalf("expected no bedrock after a clear, got %d", bedrock)
+	}
+
+	dropLeft(g)
+
+	if bedrock := f.GetCurrentBedrock(); bedrock != 2 {
+		t.Fatalf("expected the rest to materialize with the next lock, got %d bedrock", bedrock)
+	}
+}
+
+func TestIncomingWaitsForDelay(t *testing.T) {
+	g := newGarbageGame(t, 100)
+
+	g.QueueInput(&Input{Type: InputTypeGarbage, Amount: 2, SourceId: "other"})
+	g.Step()
+
+	readyAt := g.GetTick() + msToTicks(100)
+
+	dropLeft(g)
+
+	if bedrock := g.GetField().GetCurrentBedrock(); bedrock != 0 {
+		t.Fatalf("expected the bedrock to wait for its delay, got %d", bedrock)
+	}
+
+	for g.GetTick() < readyAt-1 {
+		g.Step()
+	}
+
+	dropLeft(g)
+
+	if bedrock := g.GetField().GetCurrentBedrock(); bedrock != 2 || g.GetTick() != readyAt {
+		t.Fatalf("expected 2 bedrock with the lock at tick %d, got %d at tick %d", readyAt, bedrock, g.GetTick())
+	}
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+const ModeVersus = "versus"
+const ModeSprint = "sprint"
+const ModeUltra = "ultra"
+const ModeMarathon = "marathon"
+
+const sprintLines = 40
+const ultraTimeLimit = time.Minute * 2
+const ultraTicks = int64(ultraTimeLimit / time.Second * TicksPerSecond)
+const marathonLines = 150
+
+// ResultPayload is the outcome of a single player game mode
+type ResultPayload struct {
+	Game *Payload `json:"game"`
+	Mode string   `json:"mode"`
+	// Completed is true if the goal of the mode was reached before topping out
+	Completed bool `json:"completed"`
+	// Time is the time in ms from the start until the game was finished. A line goal reached with a command is timed
+	// by when the command was received, to the ms, everything else by the ticks.
+	Time            int64   `json:"time"`
+	Score           int     `json:"score"`
+	Lines           int     `json:"lines"`
+	Level           int     `json:"level"`
+	PiecesPlaced    int     `json:"piecesPlaced"`
+	PiecesPerSecond float64 `json:"piecesPerSecond"`
+}
+
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeVersus, ModeSprint, ModeUltra, ModeMarathon:
+		return nil
+	default:
+		return errors.New("unknown mode")
+	}
+}
+
+func IsSinglePlayerMode(mode string) bool {
+	return mode == ModeSprint || mode == ModeUltra || mode == ModeMarathon
+}
+
+// isGoalReached checks the end condition of the mode at the current tick, it has to be called while updating
+func (g *Game) isGoalReached() bool {
+	reached := false
+	finishTime := ticksToDuration(g.tick).Milliseconds()
+
+	switch g.config.Mode {
+	case ModeSprint:
+		reached = g.score.GetLines() >= sprintLines
+		finishTime = g.getCommandFinishTime()
+		break
+	case ModeUltra:
+		reached = g.tick >= ultraTicks
+		break
+	case ModeMarathon:
+		reached = g.score.GetLines() >= marathonLines
+		finishTime = g.getCommandFinishTime()
+		break
+	default:
+		break
+	}
+
+	if reached {
+		g.completed = true
+		g.finishTime = finishTime
+	}
+
+	return reached
+}
+
+// getCommandFinishTime returns the time the command of the current tick was received, the line goal was reached by it.
+// The time is kept within the tick, games without a command in the tick finish at its end.
+func (g *Game) getCommandFinishTime() int64 {
+	start := ticksToDuration(g.tick - 1).Milliseconds()
+	end := ticksToDuration(g.tick).Milliseconds()
+
+	if g.commandTime == 0 {
+		return end
+	}
+
+	if g.commandTime < start {
+		return start
+	}
+
+	if g.commandTime > end {
+		return end
+	}
+
+	return g.commandTime
+}
+
+func (g *Game) GetResult() *ResultPayload {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	// the ticks stop with the game, so they are the time until it was finished unless it was finished within a tick
+	elapsed := ticksToDuration(g.tick)
+
+	if g.completed {
+		elapsed = time.Duration(g.finishTime) * time.Millisecond
+	}
+
+	piecesPerSecond := 0.0
+
+	if elapsed > 0 {
+		piecesPerSecond = float64(g.piecesPlaced) / elapsed.Seconds()
+	}
+
+	return &ResultPayload{
+		Game:            g.toPayload(),
+		Mode:            g.config.Mode,
+		Completed:       g.completed,
+		Time:            elapsed.Milliseconds(),
+		Score:           g.score.GetScore(),
+		Lines:           g.score.GetLines(),
+		Level:           g.score.GetLevel(),
+		PiecesPlaced:    g.piecesPlaced,
+		PiecesPerSecond: piecesPerSecond,
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/attack"
+	"testing"
+	"time"
+)
+
+// newModeGame starts a headless game of the mode, its field is as high as rules allow so it lasts without inputs
+func newModeGame(t *testing.T, mode string) *Game {
+	g := newHeadlessGame(t, mode)
+
+	config := *g.config
+	config.Mode = mode
+	config.FieldHeight = 40
+
+	if err := g.configure(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Start(1, time.Now())
+
+	return g
+}
+
+func TestUltraEndsAtTimeLimit(t *testing.T) {
+	g := newModeGame(t, ModeUltra)
+
+	for !g.IsOver() && g.GetTick() < ultraTicks-1 {
+		g.Step()
+	}
+
+	if g.IsOver() {
+		t.Fatalf("expected ultra to run until tick %d, it ended at %d", ultraTicks, g.GetTick())
+	}
+
+	g.Step()
+
+	res := g.GetResult()
+
+	if !g.IsOver() || !res.Completed {
+		t.Fatalf("expected ultra to be completed at tick %d", ultraTicks)
+	}
+
+	if res.Time != ultraTimeLimit.Milliseconds() {
+		t.Fatalf("expected a time of %d ms, got %d", ultraTimeLimit.Milliseconds(), res.Time)
+	}
+}
+
+func TestSprintEndsAtLineGoal(t *testing.T) {
+	g := newModeGame(t, ModeSprint)
+
+	for lines := 0; lines < sprintLines; lines += 4 {
+		if g.IsOver() {
+			t.Fatalf("expected sprint to go on at %d lines", lines)
+		}
+
+		g.GetScore().AddClear(&attack.Clear{Lines: 4})
+		g.Step()
+	}
+
+	res := g.GetResult()
+
+	if !g.IsOver() || !res.Completed || res.Lines != sprintLines {
+		t.Fatalf("expected sprint to be completed at %d lines, got %+v", sprintLines, res)
+	}
+
+	if res.Time != ticksToDuration(g.GetTick()).Milliseconds() {
+		t.Fatalf("expected the time to be derived from tick %d, got %d ms", g.GetTick(), res.Time)
+	}
+}
+
+func TestSprintIsTimedByTheCommand(t *testing.T) {
+	g := newModeGame(t, ModeSprint)
+
+	for g.GetTick() < 9 {
+		g.Step()
+	}
+
+	// tick 10 runs from 150 to 166 ms, the command finishing the sprint arrived in between
+	g.GetScore().AddClear(&attack.Clear{Lines: sprintLines})
+	g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandLeft, Time: 160})
+	g.Step()
+
+	if res := g.GetResult(); !res.Completed || res.Time != 160 {
+		t.Fatalf("expected sprint to be completed at 160 ms, got %+v", res)
+	}
+}
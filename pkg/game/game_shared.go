@@ -0,0 +1,270 @@
+package game
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/attack"
+	"github.com/nitwhiz/quadis-server/pkg/falling_piece"
+	"github.com/nitwhiz/quadis-server/pkg/field"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"github.com/nitwhiz/quadis-server/pkg/score"
+	"sync"
+	"time"
+)
+
+// Shared is the state of a co-op group: the members play on one field and share score and incoming garbage.
+// Every member controls its own falling piece, which the other members' pieces collide with.
+type Shared struct {
+	memberIds     []string
+	field         *field.Field
+	score         *score.Score
+	incoming      *Incoming
+	attackChain   *attack.Chain
+	fallingPieces map[string]*falling_piece.FallingPiece
+	members       map[string]*Game
+	// tick is the last tick the members were stepped to, stepMu serializes stepping the group
+	tick   int64
+	stepMu *sync.Mutex
+	mu     *sync.RWMutex
+}
+
+func NewShared(config *Config, seed int64, memberIds []string) *Shared {
+	return &Shared{
+		memberIds: memberIds,
+		field: field.New(&field.Settings{
+			Seed:             seed,
+			Width:            config.FieldWidth,
+			Height:           config.FieldHeight,
+			GarbageHoles:     config.GarbageHoles,
+			GarbageMessiness: config.GarbageMessiness,
+		}),
+		score:         score.New(config.StartLevel),
+		incoming:      NewIncoming(msToTicks(config.GarbageDelay)),
+		attackChain:   attack.NewChain(),
+		fallingPieces: map[string]*falling_piece.FallingPiece{},
+		members:       map[string]*Game{},
+		stepMu:        &sync.Mutex{},
+		mu:            &sync.RWMutex{},
+	}
+}
+
+func (s *Shared) GetMemberIds() []string {
+	return s.memberIds
+}
+
+func (s *Shared) addMember(g *Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members[g.id] = g
+}
+
+func (s *Shared) setFallingPiece(gameId string, fp *falling_piece.FallingPiece) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fallingPieces[gameId] = fp
+}
+
+// getLane returns the index of a member in the group
+func (s *Shared) getLane(gameId string) int {
+	for i, id := range s.memberIds {
+		if id == gameId {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// getPieceSeed derives the piece seed of a member from the round seed, so members don't get the same pieces
+func (s *Shared) getPieceSeed(gameId string, seed int64) int64 {
+	return seed + int64(s.getLane(gameId))
+}
+
+// getSpawnX returns the spawn x of a member, every member spawns in its own lane of the field
+func (s *Shared) getSpawnX(gameId string) int {
+	width := s.field.GetWidth()
+	lane := s.getLane(gameId)
+
+	laneCenter := width * (2*lane + 1) / (2 * len(s.memberIds))
+
+	return laneCenter - piece.BodyWidth/2
+}
+
+// collidesWithOthers checks if the piece would overlap the falling piece of another member
+func (s *Shared) collidesWithOthers(gameId string, p *piece.Piece, r piece.Rotation, x int, y int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, fp := range s.fallingPieces {
+		if id == gameId {
+			continue
+		}
+
+		op, or, ox, oy := fp.GetPieceAndPosition()
+
+		if op == nil {
+			continue
+		}
+
+		for px := 0; px < piece.BodyWidth; px++ {
+			for py := 0; py < piece.BodyWidth; py++ {
+				if p.GetDataXY(r, px, py) == 0 {
+					continue
+				}
+
+				opx := x + px - ox
+				opy := y + py - oy
+
+				if opx >= 0 && opx < piece.BodyWidth && opy >= 0 && opy < piece.BodyWidth && op.GetDataXY(or, opx, opy) != 0 {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// update steps the members tick by tick in a fixed order until target, so the group plays out the same every time
+func (s *Shared) update(target int64) {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	for s.tick < target {
+		s.tick++
+
+		for _, m := range s.getMembers() {
+			m.mu.Lock()
+
+			if !m.over && m.tick < s.tick {
+				m.step()
+			}
+
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Start resets every member under the step lock, the group is never stepped while only some of its members started
+func (s *Shared) Start(seed int64, startAt time.Time) {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	s.tick = 0
+
+	s.mu.Lock()
+	s.fallingPieces = map[string]*falling_piece.FallingPiece{}
+	s.mu.Unlock()
+
+	s.field.SetSeed(seed)
+	s.field.Reset()
+	s.score.Reset()
+	s.incoming.Reset()
+	s.attackChain.Reset()
+
+	for _, m := range s.getMembers() {
+		m.mu.Lock()
+		m.start(seed, startAt)
+		m.mu.Unlock()
+	}
+}
+
+// getMembers returns the members in the order of their ids
+func (s *Shared) getMembers() []*Game {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var members []*Game
+
+	for _, id := range s.memberIds {
+		if m, ok := s.members[id]; ok {
+			members = append(members, m)
+		}
+	}
+
+	return members
+}
+
+// setOver ends the game of every member, the field is lost for all of them. g is the member being stepped, its lock is held.
+func (s *Shared) setOver(g *Game) {
+	for _, m := range s.getMembers() {
+		if m == g {
+			m.setOver(false)
+			continue
+		}
+
+		m.mu.Lock()
+		m.setOver(false)
+		m.mu.Unlock()
+	}
+}
+
+// SetShared makes the game a member of a co-op group, nil gives it its own field again. The game must not be running.
+func (g *Game) SetShared(s *Shared) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.over {
+		return errors.New("game is running")
+	}
+
+	if s == nil {
+		if g.shared == nil {
+			return nil
+		}
+
+		g.shared = nil
+
+		// leaving the group, the game needs its own field again
+		return g.configure(g.config)
+	}
+
+	g.shared = s
+	g.field = s.field
+	g.score = s.score
+	g.incoming = s.incoming
+	g.attackChain = s.attackChain
+
+	s.addMember(g)
+
+	return nil
+}
+
+// GetCoopMemberIds returns the ids of the co-op group of the game, nil if it has its own field
+func (g *Game) GetCoopMemberIds() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.shared == nil {
+		return nil
+	}
+
+	return g.shared.GetMemberIds()
+}
+
+// canPutPiece checks the field and, in a co-op group, the falling pieces of the other members
+func (g *Game) canPutPiece(p *piece.Piece, r piece.Rotation, x int, y int) bool {
+	if !g.field.CanPutPiece(p, r, x, y) {
+		return false
+	}
+
+	return g.shared == nil || !g.shared.collidesWithOthers(g.id, p, r, x, y)
+}
+
+func (g *Game) getSpawnX() int {
+	if g.shared != nil {
+		return g.shared.getSpawnX(g.id)
+	}
+
+	return g.field.GetCenterX()
+}
+
+// getStateOwnerIds returns the ids of all games the field, score and incoming garbage of this game belong to
+func (g *Game) getStateOwnerIds() []string {
+	if g.shared != nil {
+		return g.shared.GetMemberIds()
+	}
+
+	return []string{g.id}
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// newCoopGroup starts two headless games sharing a field, they are stepped by the group like in a room
+func newCoopGroup(t *testing.T, seed int64) (*Shared, *Game, *Game) {
+	a := newHeadlessGame(t, "a")
+	b := newHeadlessGame(t, "b")
+
+	s := NewShared(a.config, seed, []string{"a", "b"})
+
+	for _, g := range []*Game{a, b} {
+		if err := g.SetShared(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Start(seed, time.Now())
+
+	return s, a, b
+}
+
+func TestSharedStartsAsUnit(t *testing.T) {
+	s, a, b := newCoopGroup(t, 1)
+
+	s.update(30)
+
+	// the next round starts the group again, all members begin at tick 0 together
+	a.ToggleOver(true)
+	b.ToggleOver(true)
+
+	s.Start(2, time.Now())
+	s.update(1)
+
+	if a.GetTick() != 1 || b.GetTick() != 1 {
+		t.Fatalf("expected both members at tick 1, got %d and %d", a.GetTick(), b.GetTick())
+	}
+}
+
+func TestSharedLanesAndCollision(t *testing.T) {
+	s, a, b := newCoopGroup(t, 3)
+
+	s.update(1)
+
+	_, _, ax, _ := a.GetFallingPiece().GetPieceAndPosition()
+	_, _, bx, _ := b.GetFallingPiece().GetPieceAndPosition()
+
+	if ax >= bx {
+		t.Fatalf("expected a to spawn in the left lane and b in the right one, got x %d and %d", ax, bx)
+	}
+
+	// a moves right until b's piece is in the way
+	for tick := int64(2); tick < 12; tick++ {
+		a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandRight})
+		s.update(tick)
+
+		p, r, x, y := a.GetFallingPiece().GetPieceAndPosition()
+
+		if s.collidesWithOthers("a", p, r, x, y) {
+			t.Fatalf("expected the pieces not to overlap at tick %d", tick)
+		}
+	}
+
+	p, r, x, y := a.GetFallingPiece().GetPieceAndPosition()
+
+	if !a.GetField().CanPutPiece(p, r, x+1, y) || !s.collidesWithOthers("a", p, r, x+1, y) {
+		t.Fatalf("expected the piece of a to be blocked by the piece of b at x %d", x)
+	}
+}
+
+func TestSharedState(t *testing.T) {
+	s, a, b := newCoopGroup(t, 5)
+
+	if a.GetField() != b.GetField() || a.GetScore() != b.GetScore() {
+		t.Fatal("expected the members to share field and score")
+	}
+
+	b.QueueInput(&Input{Type: InputTypeGarbage, Amount: 2, SourceId: "other"})
+	s.update(1)
+
+	if amount := a.incoming.ToPayload().Amount; amount != 2 {
+		t.Fatalf("expected the garbage received by b to be incoming for a too, got %d lines", amount)
+	}
+
+	a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+	s.update(2)
+
+	if b.GetField().IsEmpty() {
+		t.Fatal("expected the piece locked by a to be in the field of b")
+	}
+}
+
+func TestSharedTopOut(t *testing.T) {
+	s, a, b := newCoopGroup(t, 9)
+
+	// only a locks pieces, b tops out along with it
+	for tick := int64(1); tick < 5000 && !a.IsOver(); tick++ {
+		if tick%5 == 0 {
+			a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+		}
+
+		s.update(tick)
+	}
+
+	if !a.IsOver() || !b.IsOver() {
+		t.Fatalf("expected both members to be over, got %t and %t", a.IsOver(), b.IsOver())
+	}
+
+	if b.GetTick() > a.GetTick() {
+		t.Fatalf("expected b to stop with a at tick %d, got %d", a.GetTick(), b.GetTick())
+	}
+}
+
+func TestSharedStateSurvivesLateMemberStart(t *testing.T) {
+	s, a, b := newCoopGroup(t, 4)
+
+	a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+	s.update(2)
+
+	b.Start(4, time.Now())
+
+	if a.GetField().IsEmpty() {
+		t.Fatal("expected a member starting late to keep the field of its group")
+	}
+}
+
+func TestSharedSpawnIntoOtherPieceTopsOut(t *testing.T) {
+	s, a, b := newCoopGroup(t, 6)
+
+	s.update(1)
+
+	// b makes room below its spawn, a moves its piece there
+	for tick := int64(2); tick < 6; tick++ {
+		b.QueueInput(&Input{Type: InputTypeCommand, Command: CommandDown})
+		s.update(tick)
+	}
+
+	_, _, bx, _ := b.GetFallingPiece().GetPieceAndPosition()
+
+	for tick := int64(6); tick < 16; tick++ {
+		a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandRight})
+		s.update(tick)
+	}
+
+	if _, _, ax, _ := a.GetFallingPiece().GetPieceAndPosition(); ax < bx {
+		t.Fatalf("expected a to reach the spawn x %d of b, got %d", bx, ax)
+	}
+
+	b.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+	s.update(16)
+
+	if !a.IsOver() || !b.IsOver() {
+		t.Fatalf("expected the group to top out when b spawns into the piece of a, got %t and %t", a.IsOver(), b.IsOver())
+	}
+}
+
+func TestSharedFieldRisingPushesPieceUp(t *testing.T) {
+	s, a, b := newCoopGroup(t, 8)
+
+	// without a lock delay the pushed up piece would lock right away, as it is grounded on the bedrock
+	a.config.LockDelay = 500
+
+	a.QueueInput(&Input{Type: InputTypeGarbage, Amount: 8, SourceId: "other"})
+	s.update(1)
+
+	for tick := int64(2); tick < 14; tick++ {
+		a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandDown})
+		s.update(tick)
+	}
+
+	// the garbage is ready, b locks and the bedrock rises into the piece of a
+	tick := int64(14) + msToTicks(a.config.GarbageDelay)
+
+	s.update(tick - 1)
+
+	b.QueueInput(&Input{Type: InputTypeCommand, Command: CommandHardLock})
+	s.update(tick)
+
+	_, _, _, lowY := a.GetFallingPiece().GetPieceAndPosition()
+
+	s.update(tick + 1)
+
+	p, r, x, y := a.GetFallingPiece().GetPieceAndPosition()
+
+	if len(a.GetCheckpoints()) != 0 || a.GetFallingPiece().IsLocked() {
+		t.Fatal("expected the piece of a to be pushed up without locking")
+	}
+
+	if y >= lowY || !a.GetField().CanPutPiece(p, r, x, y) {
+		t.Fatalf("expected the piece of a above the bedrock, it is at y %d, was at %d", y, lowY)
+	}
+}
+
+func TestSharedGhostLandsOnOtherPiece(t *testing.T) {
+	s, a, b := newCoopGroup(t, 6)
+
+	s.update(1)
+
+	// a moves its piece down and b right above it
+	for tick := int64(2); tick < 8; tick++ {
+		a.QueueInput(&Input{Type: InputTypeCommand, Command: CommandDown})
+		s.update(tick)
+	}
+
+	_, _, ax, _ := a.GetFallingPiece().GetPieceAndPosition()
+
+	for tick := int64(8); tick < 20; tick++ {
+		if _, _, bx, _ := b.GetFallingPiece().GetPieceAndPosition(); bx > ax {
+			b.QueueInput(&Input{Type: InputTypeCommand, Command: CommandLeft})
+		} else if bx < ax {
+			b.QueueInput(&Input{Type: InputTypeCommand, Command: CommandRight})
+		}
+
+		s.update(tick)
+	}
+
+	p, r, x, _ := b.GetFallingPiece().GetPieceAndPosition()
+	ghostY := b.GetFallingPiece().ToPayload().GhostY
+
+	if x != ax {
+		t.Fatalf("expected b to reach x %d of a, got %d", ax, x)
+	}
+
+	if !b.GetField().CanPutPiece(p, r, x, ghostY+1) || b.canPutPiece(p, r, x, ghostY+1) {
+		t.Fatalf("expected the ghost of b at y %d to rest on the piece of a, not on the field", ghostY)
+	}
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/falling_piece"
+	"github.com/nitwhiz/quadis-server/pkg/field"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"github.com/nitwhiz/quadis-server/pkg/score"
+)
+
+// SnapshotPayload is the full state of a game, parts of it are nil until the game has been started
+type SnapshotPayload struct {
+	Game         *Payload               `json:"game"`
+	Field        *field.Payload         `json:"field"`
+	FallingPiece *falling_piece.Payload `json:"fallingPiece"`
+	NextPieces   *piece.QueuePayload    `json:"nextPieces"`
+	HoldingPiece *piece.Payload         `json:"holdingPiece"`
+	Score        *score.Payload         `json:"score"`
+	Incoming     *IncomingPayload       `json:"incoming"`
+	Over         bool                   `json:"over"`
+	Tick         int64                  `json:"tick"`
+}
+
+func (g *Game) ToSnapshotPayload() *SnapshotPayload {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	s := SnapshotPayload{
+		Game:     g.toPayload(),
+		Field:    g.field.ToPayload(),
+		Score:    g.score.ToPayload(),
+		Incoming: g.incoming.ToPayload(),
+		Over:     g.over,
+		Tick:     g.tick,
+	}
+
+	if g.fallingPiece != nil && g.fallingPiece.GetPiece() != nil {
+		s.FallingPiece = g.fallingPiece.ToPayload()
+	}
+
+	if g.nextPieces != nil {
+		s.NextPieces = g.nextPieces.ToPayload()
+	}
+
+	if g.holdingPiece != nil && g.holdingPiece.GetPiece() != nil {
+		s.HoldingPiece = g.holdingPiece.ToPayload()
+	}
+
+	return &s
+}
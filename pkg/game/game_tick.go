@@ -0,0 +1,178 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/gravity"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"time"
+)
+
+// TicksPerSecond is the rate the simulation runs at, a tick is exactly one gravity frame
+const TicksPerSecond = gravity.FramesPerSecond
+
+const TickDuration = time.Second / TicksPerSecond
+
+type InputType string
+
+const InputTypeCommand = InputType("command")
+const InputTypeGarbage = InputType("garbage")
+const InputTypeOverridePiece = InputType("override_piece")
+const InputTypeRotationLock = InputType("rotation_lock")
+const InputTypeShuffleField = InputType("shuffle_field")
+
+// Input is everything from outside which changes the state of a game, applied at the start of a tick.
+// The state of a game is derived only from its seed, config and inputs.
+type Input struct {
+	Tick     int64       `json:"tick"`
+	Type     InputType   `json:"type"`
+	Command  Command     `json:"command,omitempty"`
+	Amount   int         `json:"amount,omitempty"`
+	SourceId string      `json:"sourceId,omitempty"`
+	Token    piece.Token `json:"token,omitempty"`
+	Locked   bool        `json:"locked,omitempty"`
+	// Time is when a command was received in ms since the start, it times the finish of a game more precisely than its tick
+	Time int64 `json:"time,omitempty"`
+}
+
+// msToTicks converts a duration in ms to ticks, rounding up
+func msToTicks(ms int) int64 {
+	return (int64(ms)*TicksPerSecond + 999) / 1000
+}
+
+// ticksToDuration returns the time the ticks take, without the rounding error of TickDuration
+func ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks) * time.Second / TicksPerSecond
+}
+
+// ticksSince returns the number of whole ticks between startAt and now
+func ticksSince(startAt time.Time, now time.Time) int64 {
+	if now.Before(startAt) {
+		return 0
+	}
+
+	return int64(now.Sub(startAt) * TicksPerSecond / time.Second)
+}
+
+// QueueInput queues an input to be applied with the next tick, the tick of the input is set when it is applied
+func (g *Game) QueueInput(input *Input) {
+	g.inputMu.Lock()
+	defer g.inputMu.Unlock()
+
+	g.pendingInputs = append(g.pendingInputs, input)
+}
+
+func (g *Game) takePendingInputs() []*Input {
+	g.inputMu.Lock()
+	defer g.inputMu.Unlock()
+
+	inputs := g.pendingInputs
+	g.pendingInputs = nil
+
+	return inputs
+}
+
+// GetInputLog returns the inputs applied since the start, in the order they were applied
+func (g *Game) GetInputLog() []*Input {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	inputLog := make([]*Input, len(g.inputLog))
+	copy(inputLog, g.inputLog)
+
+	return inputLog
+}
+
+// GetTick returns the number of ticks the game has run since the start
+func (g *Game) GetTick() int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.tick
+}
+
+// Step runs a single tick, it is meant for headless games which are not driven by the clock
+func (g *Game) Step() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.over {
+		g.step()
+	}
+}
+
+// step advances the game by one tick, applying the pending inputs first
+func (g *Game) step() {
+	g.tick++
+	g.commandTime = 0
+
+	if g.fallingPiece == nil {
+		g.nextFallingPiece(false)
+	}
+
+	for _, input := range g.takePendingInputs() {
+		input.Tick = g.tick
+
+		g.inputLog = append(g.inputLog, input)
+
+		if input.Type == InputTypeCommand {
+			g.commandTime = input.Time
+		}
+
+		g.applyInput(input)
+	}
+
+	g.doUpdate()
+
+	g.recordStateHash()
+}
+
+func (g *Game) applyInput(input *Input) {
+	switch input.Type {
+	case InputTypeCommand:
+		g.handleCommand(input.Command)
+		break
+	case InputTypeGarbage:
+		g.incoming.Add(&Bedrock{
+			Amount:   input.Amount,
+			SourceId: input.SourceId,
+		}, g.tick)
+		break
+	case InputTypeOverridePiece:
+		g.overridePiece(piece.FromToken(input.Token))
+		break
+	case InputTypeRotationLock:
+		g.fallingPiece.SetRotationLocked(input.Locked)
+		break
+	case InputTypeShuffleField:
+		g.field.ShuffleTokens()
+		break
+	default:
+		break
+	}
+}
+
+// Update runs the ticks which are due since the start, a co-op group is updated as a whole
+func (g *Game) Update() {
+	g.mu.RLock()
+	over := g.over
+	shared := g.shared
+	startAt := g.startAt
+	g.mu.RUnlock()
+
+	if over {
+		return
+	}
+
+	target := ticksSince(startAt, time.Now())
+
+	if shared != nil {
+		shared.update(target)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for !g.over && g.tick < target {
+		g.step()
+	}
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/player"
+	"testing"
+	"time"
+)
+
+func newHeadlessGame(t *testing.T, id string) *Game {
+	g, err := New(&Settings{
+		Id:       id,
+		Player:   player.New(id),
+		Headless: true,
+		Config:   DefaultConfig(),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+// play runs a game for the ticks, queueing the inputs of the script at their ticks
+func play(g *Game, seed int64, ticks int64, script map[int64][]*Input) {
+	g.Start(seed, time.Now())
+
+	for tick := int64(1); tick <= ticks && !g.IsOver(); tick++ {
+		for _, input := range script[tick] {
+			copied := *input
+			g.QueueInput(&copied)
+		}
+
+		g.Step()
+	}
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	script := map[int64][]*Input{}
+
+	commands := []Command{CommandLeft, CommandRotate, CommandRight, CommandRight, CommandHold, CommandRotate180, CommandHardLock}
+
+	for i := int64(0); i < 300; i++ {
+		script[i*7+1] = append(script[i*7+1], &Input{Type: InputTypeCommand, Command: commands[i%int64(len(commands))]})
+	}
+
+	script[100] = append(script[100], &Input{Type: InputTypeGarbage, Amount: 3, SourceId: "other"})
+	script[300] = append(script[300], &Input{Type: InputTypeShuffleField})
+
+	a := newHeadlessGame(t, "a")
+	b := newHeadlessGame(t, "b")
+
+	play(a, 42, 2000, script)
+	play(b, 42, 2000, script)
+
+	if a.GetTick() != b.GetTick() {
+		t.Fatalf("expected both games to stop at the same tick, got %d and %d", a.GetTick(), b.GetTick())
+	}
+
+	if a.GetField().ToPayload().Data != b.GetField().ToPayload().Data {
+		t.Fatalf("expected the same fields at tick %d", a.GetTick())
+	}
+
+	if *a.GetScore().ToPayload() != *b.GetScore().ToPayload() {
+		t.Fatalf("expected the same scores at tick %d", a.GetTick())
+	}
+
+	aLog := a.GetInputLog()
+	bLog := b.GetInputLog()
+
+	if len(aLog) != len(bLog) {
+		t.Fatalf("expected the same number of applied inputs, got %d and %d", len(aLog), len(bLog))
+	}
+
+	for i := range aLog {
+		if *aLog[i] != *bLog[i] {
+			t.Fatalf("expected input %d to be the same, got %+v and %+v", i, aLog[i], bLog[i])
+		}
+	}
+}
+
+func TestTicksSince(t *testing.T) {
+	startAt := time.Now()
+
+	// a tick is a 60th of a second, TickDuration is rounded down by a fraction of a nanosecond
+	tests := []struct {
+		name  string
+		now   time.Time
+		ticks int64
+	}{
+		{name: "before the start", now: startAt.Add(-time.Second), ticks: 0},
+		{name: "at the start", now: startAt, ticks: 0},
+		{name: "just before the first tick", now: startAt.Add(TickDuration), ticks: 0},
+		{name: "at the first tick", now: startAt.Add(TickDuration + 1), ticks: 1},
+		{name: "after a second", now: startAt.Add(time.Second), ticks: TicksPerSecond},
+		{name: "after a minute", now: startAt.Add(time.Minute), ticks: TicksPerSecond * 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ticks := ticksSince(startAt, tt.now); ticks != tt.ticks {
+				t.Fatalf("expected %d ticks, got %d", tt.ticks, ticks)
+			}
+		})
+	}
+}
+
+// startedAgo starts a headless game as if it started the ticks ago, half a tick is added to stay clear of the edge
+func startedAgo(t *testing.T, ticks int64) *Game {
+	g := newHeadlessGame(t, "clock")
+
+	g.Start(1, time.Now().Add(-ticksToDuration(ticks)-TickDuration/2))
+
+	return g
+}
+
+func TestUpdateCatchesUpWithTheClock(t *testing.T) {
+	g := startedAgo(t, 30)
+
+	g.Update()
+
+	if tick := g.GetTick(); tick != 30 {
+		t.Fatalf("expected the game to catch up to tick 30, got %d", tick)
+	}
+
+	g.Update()
+
+	if tick := g.GetTick(); tick != 30 {
+		t.Fatalf("expected no tick to run twice, got %d", tick)
+	}
+}
+
+func TestUpdateAppliesInputsWithTheNextTick(t *testing.T) {
+	g := startedAgo(t, 30)
+
+	g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandLeft})
+	g.Update()
+
+	// ten more ticks are due
+	g.mu.Lock()
+	g.startAt = g.startAt.Add(-ticksToDuration(10))
+	g.mu.Unlock()
+
+	g.QueueInput(&Input{Type: InputTypeCommand, Command: CommandRight})
+	g.Update()
+
+	inputLog := g.GetInputLog()
+
+	if len(inputLog) != 2 || inputLog[0].Tick != 1 || inputLog[1].Tick != 31 {
+		t.Fatalf("expected the inputs to be applied at the ticks 1 and 31, got %+v and %+v", inputLog[0], inputLog[1])
+	}
+}
+
+func TestUpdateWaitsForTheStart(t *testing.T) {
+	g := newHeadlessGame(t, "clock")
+
+	g.Start(1, time.Now().Add(time.Minute))
+	g.Update()
+
+	if tick := g.GetTick(); tick != 0 {
+		t.Fatalf("expected no tick before the start, got %d", tick)
+	}
+}
+
+func TestUpdateStopsWhenOver(t *testing.T) {
+	g := startedAgo(t, 30)
+
+	g.ToggleOver(true)
+	g.Update()
+	g.Step()
+
+	if tick := g.GetTick(); tick != 0 {
+		t.Fatalf("expected no tick after the game is over, got %d", tick)
+	}
+}
@@ -0,0 +1,110 @@
+package gravity
+
+import (
+	"errors"
+	"math"
+)
+
+// FramesPerSecond is the frame rate gravity values refer to
+const FramesPerSecond = 60
+
+// MaxGravity drops a piece through the whole field within a single frame
+const MaxGravity = 20.0
+
+const CurveConstant = "constant"
+const CurveGuideline = "guideline"
+const CurveNES = "nes"
+const CurveCustom = "custom"
+
+const defaultGravity = 1.0 / FramesPerSecond
+
+// Curve maps a level to its gravity in G, which is cells per frame
+type Curve interface {
+	GetGravity(level int) float64
+}
+
+type Constant struct {
+	G float64
+}
+
+func (c *Constant) GetGravity(int) float64 {
+	return c.G
+}
+
+// Guideline uses the formula of the guideline, where level 1 is the first level
+type Guideline struct{}
+
+// guidelineMaxLevel is the last level of the guideline formula, it reaches MaxGravity there and its base turns negative later on
+const guidelineMaxLevel = 20
+
+func (c *Guideline) GetGravity(level int) float64 {
+	l := float64(clampLevel(level-1, guidelineMaxLevel) + 1)
+
+	secondsPerRow := math.Pow(0.8-(l-1)*0.007, l-1)
+
+	return clamp(1 / (secondsPerRow * FramesPerSecond))
+}
+
+// NES uses the frames per row of the NES version, where level 0 is the first level
+type NES struct{}
+
+var nesFramesPerRow = []float64{48, 43, 38, 33, 28, 23, 18, 13, 8, 6, 5, 5, 5, 4, 4, 4, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1}
+
+func (c *NES) GetGravity(level int) float64 {
+	return 1 / nesFramesPerRow[clampLevel(level, len(nesFramesPerRow))]
+}
+
+// Table looks up the gravity of each level, levels beyond the table keep the last value
+type Table struct {
+	G []float64
+}
+
+func (c *Table) GetGravity(level int) float64 {
+	return c.G[clampLevel(level, len(c.G))]
+}
+
+func clamp(g float64) float64 {
+	return math.Min(g, MaxGravity)
+}
+
+func clampLevel(level int, levelCount int) int {
+	if level < 0 {
+		return 0
+	}
+
+	if level >= levelCount {
+		return levelCount - 1
+	}
+
+	return level
+}
+
+// New returns the named curve, table is used by the custom curve and the first entry of it by the constant curve
+func New(name string, table []float64) (Curve, error) {
+	for _, g := range table {
+		if g <= 0 || g > MaxGravity {
+			return nil, errors.New("gravity out of range")
+		}
+	}
+
+	switch name {
+	case CurveConstant:
+		if len(table) == 0 {
+			return &Constant{G: defaultGravity}, nil
+		}
+
+		return &Constant{G: table[0]}, nil
+	case CurveGuideline:
+		return &Guideline{}, nil
+	case CurveNES:
+		return &NES{}, nil
+	case CurveCustom:
+		if len(table) == 0 {
+			return nil, errors.New("custom gravity curve needs a table")
+		}
+
+		return &Table{G: table}, nil
+	default:
+		return nil, errors.New("unknown gravity curve")
+	}
+}
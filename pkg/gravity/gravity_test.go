@@ -0,0 +1,27 @@
+package gravity
+
+import "testing"
+
+func TestGuidelineHighLevels(t *testing.T) {
+	c := &Guideline{}
+
+	last := 0.0
+
+	for level := 0; level <= 300; level++ {
+		g := c.GetGravity(level)
+
+		if g <= 0 || g > MaxGravity {
+			t.Fatalf("expected the gravity of level %d to be in (0, %v], got %v", level, MaxGravity, g)
+		}
+
+		if g < last {
+			t.Fatalf("expected the gravity not to fall from level %d to %d, got %v after %v", level-1, level, g, last)
+		}
+
+		last = g
+	}
+
+	if g := c.GetGravity(200); g != MaxGravity {
+		t.Fatalf("expected level 200 to have %v, got %v", MaxGravity, g)
+	}
+}
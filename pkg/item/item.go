@@ -23,6 +23,7 @@ type Room interface {
 	GetGames() map[string]*game.Game
 	GetEventBus() *event.Bus
 	UpdateItemAffection(gameId string, itemType string)
+	AreOpponents(a *game.Game, b *game.Game) bool
 }
 
 type ActivateFunc func(sourceGame *game.Game, room Room)
@@ -32,6 +33,23 @@ type Item struct {
 	Activate ActivateFunc
 }
 
+// getOpponentTarget returns the running game targeted by the source, nil if there is none or it is no opponent of the source
+func getOpponentTarget(sourceGame *game.Game, room Room) *game.Game {
+	targetId := room.GetTargetGameId(sourceGame.GetId())
+
+	if targetId == "" {
+		return nil
+	}
+
+	targetGame := room.GetGame(targetId)
+
+	if targetGame == nil || targetGame.IsOver() || !room.AreOpponents(sourceGame, targetGame) {
+		return nil
+	}
+
+	return targetGame
+}
+
 var All = []*Item{
 	NewTornado(),
 	NewOnlyIPieces(),
@@ -13,27 +13,21 @@ func NewLockRotation() *Item {
 				return
 			}
 
-			targetId := room.GetTargetGameId(sourceGame.GetId())
+			targetGame := getOpponentTarget(sourceGame, room)
 
-			if targetId == "" {
+			if targetGame == nil {
 				return
 			}
 
-			room.UpdateItemAffection(targetId, TypeLockRotation)
-
-			targetGame := room.GetGame(targetId)
-
-			if targetGame == nil || targetGame.IsOver() {
-				return
-			}
+			targetId := targetGame.GetId()
 
-			fp := targetGame.GetFallingPiece()
+			room.UpdateItemAffection(targetId, TypeLockRotation)
 
-			fp.SetRotationLocked(true)
+			targetGame.SetRotationLocked(true)
 
 			<-time.After(time.Second * 5)
 
-			fp.SetRotationLocked(false)
+			targetGame.SetRotationLocked(false)
 
 			room.UpdateItemAffection(targetId, TypeNone)
 		},
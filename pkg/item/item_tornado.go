@@ -12,21 +12,15 @@ func NewTornado() *Item {
 				return
 			}
 
-			targetId := room.GetTargetGameId(sourceGame.GetId())
+			targetGame := getOpponentTarget(sourceGame, room)
 
-			if targetId == "" {
+			if targetGame == nil {
 				return
 			}
 
 			room.UpdateItemAffection(sourceGame.GetId(), TypeTornado)
 
-			targetGame := room.GetGame(targetId)
-
-			if targetGame == nil || targetGame.IsOver() {
-				return
-			}
-
-			targetGame.GetField().ShuffleTokens()
+			targetGame.ShuffleField()
 
 			room.UpdateItemAffection(sourceGame.GetId(), TypeNone)
 		},
@@ -16,6 +16,7 @@ var pieceTokenNames = map[piece.Token]string{
 	piece.TokenT:       "T",
 	piece.TokenZ:       "Z",
 	piece.TokenBedrock: "Bedrock",
+	piece.TokenGarbage: "Garbage",
 }
 
 const appName = "quadis"
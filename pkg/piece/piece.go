@@ -10,6 +10,11 @@ type Body []Token
 type Tokens = Body
 type Rotation int
 
+const RotationSpawn = Rotation(0)
+const RotationRight = Rotation(1)
+const RotationReverse = Rotation(2)
+const RotationLeft = Rotation(3)
+
 const BodyWidth = 4
 
 const TokenNone = Token(0)
@@ -23,8 +28,9 @@ const TokenT = Token(6)
 const TokenZ = Token(7)
 
 const TokenBedrock = Token(8)
+const TokenGarbage = Token(9)
 
-const MaxToken = TokenBedrock
+const MaxToken = TokenGarbage
 
 type Piece struct {
 	Token        Token
@@ -47,8 +53,11 @@ func (d Tokens) MarshalJSON() ([]byte, error) {
 	return []byte(result), nil
 }
 
+// ClampRotation maps any rotation, including negative ones, to one of the pieces faces
 func (p *Piece) ClampRotation(rot Rotation) Rotation {
-	return rot % Rotation(len(*p.rotatedFaces))
+	faceCount := Rotation(len(*p.rotatedFaces))
+
+	return (rot%faceCount + faceCount) % faceCount
 }
 
 func (p *Piece) GetData(rot Rotation) *Body {
@@ -63,10 +72,10 @@ var I = Piece{
 	Token: TokenI,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
 			0, 0, 0, 0,
 			TokenI, TokenI, TokenI, TokenI,
 			0, 0, 0, 0,
+			0, 0, 0, 0,
 		},
 		{
 			0, 0, TokenI, 0,
@@ -74,6 +83,18 @@ var I = Piece{
 			0, 0, TokenI, 0,
 			0, 0, TokenI, 0,
 		},
+		{
+			0, 0, 0, 0,
+			0, 0, 0, 0,
+			TokenI, TokenI, TokenI, TokenI,
+			0, 0, 0, 0,
+		},
+		{
+			0, TokenI, 0, 0,
+			0, TokenI, 0, 0,
+			0, TokenI, 0, 0,
+			0, TokenI, 0, 0,
+		},
 	},
 }
 
@@ -81,10 +102,28 @@ var O = Piece{
 	Token: TokenO,
 	rotatedFaces: &[]Body{
 		{
+			0, TokenO, TokenO, 0,
+			0, TokenO, TokenO, 0,
 			0, 0, 0, 0,
+			0, 0, 0, 0,
+		},
+		{
 			0, TokenO, TokenO, 0,
 			0, TokenO, TokenO, 0,
 			0, 0, 0, 0,
+			0, 0, 0, 0,
+		},
+		{
+			0, TokenO, TokenO, 0,
+			0, TokenO, TokenO, 0,
+			0, 0, 0, 0,
+			0, 0, 0, 0,
+		},
+		{
+			0, TokenO, TokenO, 0,
+			0, TokenO, TokenO, 0,
+			0, 0, 0, 0,
+			0, 0, 0, 0,
 		},
 	},
 }
@@ -93,25 +132,25 @@ var L = Piece{
 	Token: TokenL,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
-			TokenL, TokenL, TokenL, 0,
 			0, 0, TokenL, 0,
+			TokenL, TokenL, TokenL, 0,
+			0, 0, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
 			0, TokenL, 0, 0,
 			0, TokenL, 0, 0,
-			TokenL, TokenL, 0, 0,
+			0, TokenL, TokenL, 0,
 			0, 0, 0, 0,
 		},
 		{
-			TokenL, 0, 0, 0,
-			TokenL, TokenL, TokenL, 0,
 			0, 0, 0, 0,
+			TokenL, TokenL, TokenL, 0,
+			TokenL, 0, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
-			0, TokenL, TokenL, 0,
+			TokenL, TokenL, 0, 0,
 			0, TokenL, 0, 0,
 			0, TokenL, 0, 0,
 			0, 0, 0, 0,
@@ -123,27 +162,27 @@ var J = Piece{
 	Token: TokenJ,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
-			TokenJ, TokenJ, TokenJ, 0,
 			TokenJ, 0, 0, 0,
+			TokenJ, TokenJ, TokenJ, 0,
+			0, 0, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
-			TokenJ, TokenJ, 0, 0,
+			0, TokenJ, TokenJ, 0,
 			0, TokenJ, 0, 0,
 			0, TokenJ, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
-			0, 0, TokenJ, 0,
-			TokenJ, TokenJ, TokenJ, 0,
 			0, 0, 0, 0,
+			TokenJ, TokenJ, TokenJ, 0,
+			0, 0, TokenJ, 0,
 			0, 0, 0, 0,
 		},
 		{
 			0, TokenJ, 0, 0,
 			0, TokenJ, 0, 0,
-			0, TokenJ, TokenJ, 0,
+			TokenJ, TokenJ, 0, 0,
 			0, 0, 0, 0,
 		},
 	},
@@ -153,10 +192,10 @@ var S = Piece{
 	Token: TokenS,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
 			0, TokenS, TokenS, 0,
 			TokenS, TokenS, 0, 0,
 			0, 0, 0, 0,
+			0, 0, 0, 0,
 		},
 		{
 			0, TokenS, 0, 0,
@@ -171,9 +210,9 @@ var S = Piece{
 			0, 0, 0, 0,
 		},
 		{
+			TokenS, 0, 0, 0,
+			TokenS, TokenS, 0, 0,
 			0, TokenS, 0, 0,
-			0, TokenS, TokenS, 0,
-			0, 0, TokenS, 0,
 			0, 0, 0, 0,
 		},
 	},
@@ -183,26 +222,26 @@ var T = Piece{
 	Token: TokenT,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
-			TokenT, TokenT, TokenT, 0,
 			0, TokenT, 0, 0,
+			TokenT, TokenT, TokenT, 0,
+			0, 0, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
 			0, TokenT, 0, 0,
-			TokenT, TokenT, 0, 0,
+			0, TokenT, TokenT, 0,
 			0, TokenT, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
-			0, TokenT, 0, 0,
-			TokenT, TokenT, TokenT, 0,
 			0, 0, 0, 0,
+			TokenT, TokenT, TokenT, 0,
+			0, TokenT, 0, 0,
 			0, 0, 0, 0,
 		},
 		{
 			0, TokenT, 0, 0,
-			0, TokenT, TokenT, 0,
+			TokenT, TokenT, 0, 0,
 			0, TokenT, 0, 0,
 			0, 0, 0, 0,
 		},
@@ -213,10 +252,10 @@ var Z = Piece{
 	Token: TokenZ,
 	rotatedFaces: &[]Body{
 		{
-			0, 0, 0, 0,
 			TokenZ, TokenZ, 0, 0,
 			0, TokenZ, TokenZ, 0,
 			0, 0, 0, 0,
+			0, 0, 0, 0,
 		},
 		{
 			0, 0, TokenZ, 0,
@@ -231,14 +270,35 @@ var Z = Piece{
 			0, 0, 0, 0,
 		},
 		{
-			0, 0, TokenZ, 0,
-			0, TokenZ, TokenZ, 0,
 			0, TokenZ, 0, 0,
+			TokenZ, TokenZ, 0, 0,
+			TokenZ, 0, 0, 0,
 			0, 0, 0, 0,
 		},
 	},
 }
 
+var byLetter = map[rune]*Piece{
+	'I': &I,
+	'O': &O,
+	'L': &L,
+	'J': &J,
+	'S': &S,
+	'T': &T,
+	'Z': &Z,
+}
+
+// FromToken returns the piece of a token, nil if there is none
+func FromToken(token Token) *Piece {
+	for _, p := range All {
+		if p.Token == token {
+			return p
+		}
+	}
+
+	return nil
+}
+
 var All = []*Piece{
 	&T,
 	&L,
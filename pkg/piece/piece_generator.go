@@ -1,21 +1,105 @@
 package piece
 
-import "github.com/nitwhiz/quadis-server/pkg/rng"
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/rng"
+	"strings"
+)
+
+const Randomizer7Bag = "7bag"
+const Randomizer14Bag = "14bag"
+const RandomizerHistory = "history"
+const RandomizerNES = "nes"
+const RandomizerUniform = "uniform"
+const RandomizerSequence = "sequence"
 
 type Generator struct {
-	*rng.Bag[*Piece]
+	rng.Randomizer[*Piece]
 }
 
-func NewGenerator(seed int64) *Generator {
+// GeneratorFactory creates generators, which yield the same pieces for the same seed
+type GeneratorFactory func(seed int64) *Generator
+
+func newBagGenerator(seed int64, copies int) *Generator {
 	return &Generator{
 		rng.NewBag[*Piece](seed, func() []*Piece {
 			var b []*Piece
 
-			for _, p := range All {
-				b = append(b, p)
+			for i := 0; i < copies; i++ {
+				for _, p := range All {
+					b = append(b, p)
+				}
 			}
 
 			return b
 		}),
 	}
 }
+
+// ParseSequence reads a sequence of piece letters, like "TLJ"
+func ParseSequence(sequence string) ([]*Piece, error) {
+	var pieces []*Piece
+
+	for _, l := range strings.ToUpper(sequence) {
+		p, ok := byLetter[l]
+
+		if !ok {
+			return nil, errors.New("unknown piece in sequence")
+		}
+
+		pieces = append(pieces, p)
+	}
+
+	if len(pieces) == 0 {
+		return nil, errors.New("empty sequence")
+	}
+
+	return pieces, nil
+}
+
+// NewGeneratorFactory returns a factory for the named randomizer, sequence is only used by the sequence randomizer
+func NewGeneratorFactory(randomizer string, sequence string) (GeneratorFactory, error) {
+	switch randomizer {
+	case Randomizer7Bag:
+		return func(seed int64) *Generator {
+			return newBagGenerator(seed, 1)
+		}, nil
+	case Randomizer14Bag:
+		return func(seed int64) *Generator {
+			return newBagGenerator(seed, 2)
+		}, nil
+	case RandomizerHistory:
+		// history of 4 with 6 rerolls, the initial history keeps s and z from being dealt first
+		return func(seed int64) *Generator {
+			return &Generator{
+				rng.NewHistory[*Piece](seed, All, []*Piece{&Z, &Z, &S, &S}, 6),
+			}
+		}, nil
+	case RandomizerNES:
+		return func(seed int64) *Generator {
+			return &Generator{
+				rng.NewReroll[*Piece](seed, All),
+			}
+		}, nil
+	case RandomizerUniform:
+		return func(seed int64) *Generator {
+			return &Generator{
+				rng.NewUniform[*Piece](seed, All),
+			}
+		}, nil
+	case RandomizerSequence:
+		pieces, err := ParseSequence(sequence)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return func(int64) *Generator {
+			return &Generator{
+				rng.NewSequence[*Piece](pieces),
+			}
+		}, nil
+	default:
+		return nil, errors.New("unknown randomizer")
+	}
+}
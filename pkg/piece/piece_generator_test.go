@@ -0,0 +1,86 @@
+package piece
+
+import (
+	"testing"
+)
+
+var randomizers = []string{
+	Randomizer7Bag,
+	Randomizer14Bag,
+	RandomizerHistory,
+	RandomizerNES,
+	RandomizerUniform,
+	RandomizerSequence,
+}
+
+func takeTokens(g *Generator, n int) []Token {
+	var tokens []Token
+
+	for i := 0; i < n; i++ {
+		tokens = append(tokens, g.NextElement().Token)
+	}
+
+	return tokens
+}
+
+func TestGeneratorsAreDeterministic(t *testing.T) {
+	for _, r := range randomizers {
+		newGenerator, err := NewGeneratorFactory(r, "TLI")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a := takeTokens(newGenerator(42), 100)
+		b := takeTokens(newGenerator(42), 100)
+
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("randomizer %s yields different pieces for the same seed at #%d", r, i)
+			}
+		}
+	}
+}
+
+func TestBagGeneratorDealsEveryPiecePerBag(t *testing.T) {
+	for copies, r := range map[int]string{1: Randomizer7Bag, 2: Randomizer14Bag} {
+		newGenerator, _ := NewGeneratorFactory(r, "")
+
+		g := newGenerator(1)
+		bagSize := len(All) * copies
+
+		for bag := 0; bag < 10; bag++ {
+			counts := map[Token]int{}
+
+			for _, tok := range takeTokens(g, bagSize) {
+				counts[tok]++
+			}
+
+			for _, p := range All {
+				if counts[p.Token] != copies {
+					t.Fatalf("randomizer %s dealt token %d %d times in bag #%d", r, p.Token, counts[p.Token], bag)
+				}
+			}
+		}
+	}
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	newGenerator, err := NewGeneratorFactory(RandomizerSequence, "tlo")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Token{TokenT, TokenL, TokenO, TokenT, TokenL}
+
+	for i, tok := range takeTokens(newGenerator(0), len(expected)) {
+		if tok != expected[i] {
+			t.Fatalf("expected token %d at #%d, got %d", expected[i], i, tok)
+		}
+	}
+
+	if _, err := NewGeneratorFactory(RandomizerSequence, "TQ"); err == nil {
+		t.Fatal("expected an unknown piece letter to be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+package piece
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/dirty"
+	"sync"
+)
+
+// Queue holds the upcoming pieces in the order they are played
+type Queue struct {
+	pieces    []*Piece
+	override  *Piece
+	generator *Generator
+	Dirty     *dirty.Dirtiness
+	mu        *sync.RWMutex
+}
+
+type QueuePayload struct {
+	Tokens Tokens `json:"tokens"`
+}
+
+func NewQueue(generator *Generator, length int) *Queue {
+	q := Queue{
+		pieces:    make([]*Piece, length),
+		override:  nil,
+		generator: generator,
+		Dirty:     dirty.New(),
+		mu:        &sync.RWMutex{},
+	}
+
+	for i := range q.pieces {
+		q.pieces[i] = generator.NextElement()
+	}
+
+	q.Dirty.Trip()
+
+	return &q
+}
+
+func (q *Queue) ToPayload() *QueuePayload {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	tokens := Tokens{}
+
+	for _, p := range q.pieces {
+		if q.override != nil {
+			p = q.override
+		}
+
+		tokens = append(tokens, p.Token)
+	}
+
+	return &QueuePayload{
+		Tokens: tokens,
+	}
+}
+
+// Peek returns the piece which is played next
+func (q *Queue) Peek() *Piece {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.override != nil {
+		return q.override
+	}
+
+	return q.pieces[0]
+}
+
+// Next removes the piece which is played next from the queue and returns it
+func (q *Queue) Next() *Piece {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.override != nil {
+		return q.override
+	}
+
+	p := q.pieces[0]
+
+	q.pieces = append(q.pieces[1:], q.generator.NextElement())
+
+	q.Dirty.Trip()
+
+	return p
+}
+
+// SetOverride replaces all upcoming pieces with p, until it is set to nil again
+func (q *Queue) SetOverride(p *Piece) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.override != p {
+		q.override = p
+		q.Dirty.Trip()
+	}
+}
@@ -0,0 +1,78 @@
+package piece
+
+import (
+	"testing"
+)
+
+func newSequenceQueue(t *testing.T, sequence string, length int) *Queue {
+	newGenerator, err := NewGeneratorFactory(RandomizerSequence, sequence)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewQueue(newGenerator(1), length)
+}
+
+func expectTokens(t *testing.T, q *Queue, want ...Token) {
+	t.Helper()
+
+	got := q.ToPayload().Tokens
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tokens in the queue, got %v", len(want), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v in the queue, got %v", want, got)
+		}
+	}
+}
+
+func TestQueuePeekAndNext(t *testing.T) {
+	q := newSequenceQueue(t, "TLOS", 3)
+
+	expectTokens(t, q, TokenT, TokenL, TokenO)
+
+	for _, want := range []Token{TokenT, TokenL, TokenO, TokenS, TokenT} {
+		if p := q.Peek(); p.Token != want {
+			t.Fatalf("expected to peek %d, got %d", want, p.Token)
+		}
+
+		if p := q.Next(); p.Token != want {
+			t.Fatalf("expected %d to be next, got %d", want, p.Token)
+		}
+	}
+
+	// the queue is refilled with every piece taken
+	expectTokens(t, q, TokenL, TokenO, TokenS)
+}
+
+func TestQueueOverrideIsRestored(t *testing.T) {
+	q := newSequenceQueue(t, "TLOS", 3)
+
+	q.Dirty.Clear()
+	q.SetOverride(&I)
+
+	if !q.Dirty.Clear() {
+		t.Fatal("expected the override to change the queue")
+	}
+
+	expectTokens(t, q, TokenI, TokenI, TokenI)
+
+	for i := 0; i < 5; i++ {
+		if q.Peek() != &I || q.Next() != &I {
+			t.Fatal("expected only I pieces while overridden")
+		}
+	}
+
+	q.SetOverride(nil)
+
+	// the pieces queued before the override are played afterwards, none were taken in between
+	expectTokens(t, q, TokenT, TokenL, TokenO)
+
+	if p := q.Next(); p.Token != TokenT {
+		t.Fatalf("expected T to be next after the override, got %d", p.Token)
+	}
+}
@@ -0,0 +1,331 @@
+package playback
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/nitwhiz/quadis-server/pkg/communication"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/item"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
+	"github.com/nitwhiz/quadis-server/pkg/room"
+	"sync"
+	"time"
+)
+
+const minSpeed = .5
+const maxSpeed = 4
+
+// Playback streams a replay to a single connection by simulating its games again, the viewer controls the playback
+type Playback struct {
+	id         string
+	replay     *replay.Replay
+	simulation *replay.Simulation
+	// roundIndex is the index of the round being played in the rounds of the replay
+	roundIndex int
+	paused     bool
+	speed      float64
+	// progress is the fraction of a tick which is due, so speeds below 1 step every other tick
+	progress float64
+	con      *communication.Connection
+	bus      *event.Bus
+	ctx      context.Context
+	stop     context.CancelFunc
+	wg       *sync.WaitGroup
+	mu       *sync.Mutex
+}
+
+type StatePayload struct {
+	ReplayId string `json:"replayId"`
+	// Round is the number of the round being played, starting at 1
+	Round    int     `json:"round"`
+	Rounds   int     `json:"rounds"`
+	Tick     int64   `json:"tick"`
+	LastTick int64   `json:"lastTick"`
+	Paused   bool    `json:"paused"`
+	Speed    float64 `json:"speed"`
+	Ended    bool    `json:"ended"`
+}
+
+// Connect runs the handshake on a new websocket and starts playing the replay on it
+func Connect(parentContext context.Context, rep *replay.Replay, ws *websocket.Conn) error {
+	if len(rep.Rounds) == 0 {
+		_ = ws.Close()
+
+		return errors.New("replay has no rounds")
+	}
+
+	ctx, stop := context.WithCancel(parentContext)
+
+	p := Playback{
+		id:     uuid.NewString(),
+		replay: rep,
+		paused: false,
+		speed:  1,
+		bus:    event.NewBus(ctx),
+		ctx:    ctx,
+		stop:   stop,
+		wg:     &sync.WaitGroup{},
+		mu:     &sync.Mutex{},
+	}
+
+	p.con = communication.NewConnection(&communication.Settings{
+		WS:            ws,
+		ParentContext: ctx,
+		PreStopCallback: func(c *communication.Connection) {
+			p.Stop()
+		},
+	})
+
+	if err := p.start(); err != nil {
+		go p.con.Stop()
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *Playback) start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.handshake(); err != nil {
+		return err
+	}
+
+	p.bus.Subscribe(p.id, p.con)
+
+	if err := p.seek(0, 0); err != nil {
+		return err
+	}
+
+	go p.startCommandReader()
+	go p.startPlayer()
+
+	return nil
+}
+
+// Stop ends the playback, the connection stops with it
+func (p *Playback) Stop() {
+	p.stop()
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.simulation != nil {
+		p.simulation.Stop()
+	}
+}
+
+// send writes an event to the connection, bypassing the event bus
+func (p *Playback) send(eventType string, payload any) error {
+	now := time.Now().UnixMilli()
+
+	msg, err := (&event.Event{
+		Type:        eventType,
+		Origin:      event.OriginRoom(p.replay.Id),
+		Payload:     payload,
+		PublishedAt: now,
+		SentAt:      now,
+	}).Serialize()
+
+	if err != nil {
+		return err
+	}
+
+	p.con.Write(msg)
+
+	return nil
+}
+
+func (p *Playback) publish(eventType string, payload any) {
+	p.bus.Publish(&event.Event{
+		Type:    eventType,
+		Origin:  event.OriginRoom(p.replay.Id),
+		Payload: payload,
+	})
+}
+
+// handshake greets the client like a room does, the client is always a spectator of a playback
+func (p *Playback) handshake() error {
+	if err := p.send(event.TypeHello, nil); err != nil {
+		return err
+	}
+
+	select {
+	case <-p.ctx.Done():
+		return errors.New("playback stopped")
+	case resp := <-p.con.GetInputChannel():
+		if resp == "" {
+			return errors.New("empty hello response")
+		}
+		break
+	case <-time.After(time.Second * 10):
+		return errors.New("hello response timed out")
+	}
+
+	rules, err := room.ParseRules(p.replay.Rounds[0].Rules)
+
+	if err != nil {
+		return err
+	}
+
+	return p.send(event.TypeHelloAck, &room.HelloAckPayload{
+		Room:      p.toRoomPayload(p.replay.Rounds[0]),
+		Rules:     rules,
+		Spectator: true,
+	})
+}
+
+// seek simulates the round up to the tick without publishing, then tells the viewer where the playback is now
+func (p *Playback) seek(roundIndex int, tick int64) error {
+	if roundIndex < 0 || roundIndex >= len(p.replay.Rounds) {
+		return errors.New("round out of range")
+	}
+
+	if tick < 0 {
+		return errors.New("tick out of range")
+	}
+
+	if p.simulation == nil || roundIndex != p.roundIndex || tick < p.simulation.GetTick() {
+		round := p.replay.Rounds[roundIndex]
+
+		rules, err := room.ParseRules(round.Rules)
+
+		if err != nil {
+			return err
+		}
+
+		simulation, err := replay.NewSimulation(round, rules.ToGameConfig(), nil)
+
+		if err != nil {
+			return err
+		}
+
+		if p.simulation != nil {
+			p.simulation.Stop()
+		}
+
+		p.simulation = simulation
+		p.roundIndex = roundIndex
+
+		p.publish(event.TypeRulesUpdate, rules)
+	}
+
+	p.simulation.SetEventBus(nil)
+	p.simulation.StepTo(tick)
+	p.simulation.SetEventBus(p.bus)
+
+	p.progress = 0
+
+	p.publish(event.TypeRoomSnapshot, p.toSnapshotPayload())
+	p.publishState()
+
+	return nil
+}
+
+func (p *Playback) publishState() {
+	p.publish(event.TypePlaybackUpdate, &StatePayload{
+		ReplayId: p.replay.Id,
+		Round:    p.roundIndex + 1,
+		Rounds:   len(p.replay.Rounds),
+		Tick:     p.simulation.GetTick(),
+		LastTick: p.simulation.GetLastTick(),
+		Paused:   p.paused,
+		Speed:    p.speed,
+		Ended:    p.isEnded(),
+	})
+}
+
+func (p *Playback) isEnded() bool {
+	return p.simulation.IsDone() && p.roundIndex == len(p.replay.Rounds)-1
+}
+
+func (p *Playback) toRoomPayload(round *replay.Round) *room.Payload {
+	rp := room.Payload{
+		Id:    p.replay.Id,
+		Games: []*game.Payload{},
+	}
+
+	for _, rg := range round.Games {
+		rp.Games = append(rp.Games, &game.Payload{
+			Id:         rg.Id,
+			PlayerName: rg.PlayerName,
+			Connected:  true,
+			Team:       rg.Team,
+		})
+	}
+
+	return &rp
+}
+
+func (p *Playback) toSnapshotPayload() *room.SnapshotPayload {
+	s := room.SnapshotPayload{
+		Room:       p.toRoomPayload(p.simulation.GetRound()),
+		Started:    true,
+		Games:      []*room.GameSnapshotPayload{},
+		Targets:    map[string]string{},
+		Strategies: map[string]string{},
+		Match: &room.MatchPayload{
+			InProgress: true,
+			Round:      p.simulation.GetRound().Number,
+			Points:     map[string]int{},
+		},
+	}
+
+	for _, g := range p.simulation.GetGames() {
+		s.Games = append(s.Games, &room.GameSnapshotPayload{
+			SnapshotPayload: g.ToSnapshotPayload(),
+			Affection:       item.TypeNone,
+		})
+	}
+
+	return &s
+}
+
+// startPlayer steps the simulation in real time times the speed, moving on to the next round when one is done
+func (p *Playback) startPlayer() {
+	p.wg.Add(1)
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(game.TickDuration):
+			p.update()
+			break
+		}
+	}
+}
+
+func (p *Playback) update() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused || p.isEnded() {
+		return
+	}
+
+	p.progress += p.speed
+
+	for p.progress >= 1 {
+		p.progress -= 1
+
+		if p.simulation.IsDone() {
+			_ = p.seek(p.roundIndex+1, 0)
+			return
+		}
+
+		p.simulation.Step()
+
+		// the viewer gets the position once a second and when the playback ends
+		if p.simulation.GetTick()%game.TicksPerSecond == 0 || p.isEnded() {
+			p.publishState()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package playback
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/room"
+	"time"
+)
+
+const CommandTypePause = "pause"
+const CommandTypeResume = "resume"
+const CommandTypeSeek = "seek"
+const CommandTypeSpeed = "speed"
+
+type SeekPayload struct {
+	// Round is the number of the round to seek in, 0 seeks in the current round
+	Round int   `json:"round"`
+	Tick  int64 `json:"tick"`
+}
+
+type SpeedPayload struct {
+	Speed float64 `json:"speed"`
+}
+
+// startCommandReader passes the commands of the viewer to the playback
+func (p *Playback) startCommandReader() {
+	p.wg.Add(1)
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case cmd := <-p.con.GetInputChannel():
+			p.HandleCommand(cmd)
+			break
+		case <-time.After(time.Millisecond * 250):
+			break
+		}
+	}
+}
+
+// HandleCommand runs a json encoded playback command, they look like room commands
+func (p *Playback) HandleCommand(msg string) {
+	cmd := room.Command{}
+
+	if err := json.Unmarshal([]byte(msg), &cmd); err != nil {
+		p.sendCommandError(&cmd, errors.New("malformed command"))
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+
+	switch cmd.Type {
+	case room.CommandTypeSnapshot:
+		err = p.send(event.TypeRoomSnapshot, p.toSnapshotPayload())
+		break
+	case CommandTypePause:
+		p.paused = true
+		p.publishState()
+		break
+	case CommandTypeResume:
+		p.paused = false
+		p.publishState()
+		break
+	case CommandTypeSeek:
+		var sp SeekPayload
+
+		if json.Unmarshal(cmd.Payload, &sp) != nil {
+			err = errors.New("malformed payload")
+		} else if sp.Round == 0 {
+			err = p.seek(p.roundIndex, sp.Tick)
+		} else {
+			err = p.seek(sp.Round-1, sp.Tick)
+		}
+		break
+	case CommandTypeSpeed:
+		var sp SpeedPayload
+
+		if json.Unmarshal(cmd.Payload, &sp) != nil {
+			err = errors.New("malformed payload")
+		} else if sp.Speed < minSpeed || sp.Speed > maxSpeed {
+			err = errors.New("speed out of range")
+		} else {
+			p.speed = sp.Speed
+			p.publishState()
+		}
+		break
+	default:
+		err = errors.New("unknown command")
+		break
+	}
+
+	if err != nil {
+		p.sendCommandError(&cmd, err)
+	}
+}
+
+func (p *Playback) sendCommandError(cmd *room.Command, err error) {
+	_ = p.send(event.TypeCommandError, &room.CommandErrorPayload{
+		Type:  cmd.Type,
+		Error: err.Error(),
+	})
+}
@@ -0,0 +1,263 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/player"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
+	"github.com/nitwhiz/quadis-server/pkg/room"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRoundTicks = 600
+
+// newTestReplay records two rounds of a single headless game dropping a piece every second
+func newTestReplay(t *testing.T) *replay.Replay {
+	rep := replay.New("room")
+
+	for number := 1; number <= 2; number++ {
+		g, err := game.New(&game.Settings{
+			Id:       "a",
+			Player:   player.New("a"),
+			Config:   room.DefaultRules().ToGameConfig(),
+			Headless: true,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g.Start(int64(number), time.Now())
+
+		for tick := int64(1); tick <= testRoundTicks; tick++ {
+			if tick%game.TicksPerSecond == 0 {
+				g.QueueInput(&game.Input{Type: game.InputTypeCommand, Command: game.CommandHardLock})
+			}
+
+			g.Step()
+		}
+
+		g.ToggleOver(true)
+
+		rep.AddRound(replay.NewRound(number, int64(number), json.RawMessage(`{}`), time.Now(), []*game.Game{g}))
+	}
+
+	return rep
+}
+
+type testEvent struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// testViewer is a websocket client watching a playback
+type testViewer struct {
+	t       *testing.T
+	ws      *websocket.Conn
+	pending []*testEvent
+}
+
+func watch(t *testing.T, rep *replay.Replay) *testViewer {
+	ctx, cancel := context.WithCancel(context.Background())
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ws, err := upgrader.Upgrade(w, req, nil)
+
+		if err == nil {
+			_ = Connect(ctx, rep, ws)
+		}
+	}))
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+
+	if err != nil {
+		t.Fatalf("unable to dial: %s", err)
+	}
+
+	t.Cleanup(func() {
+		_ = ws.Close()
+		cancel()
+		srv.Close()
+	})
+
+	v := &testViewer{t: t, ws: ws}
+
+	v.await(event.TypeHello)
+	v.send(`{}`)
+	v.await(event.TypeHelloAck)
+
+	return v
+}
+
+func (v *testViewer) send(msg string) {
+	v.t.Helper()
+
+	if err := v.ws.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		v.t.Fatalf("unable to send %s: %s", msg, err)
+	}
+}
+
+// await skips events until one of the type arrives, window events are unpacked
+func (v *testViewer) await(eventType string) *testEvent {
+	v.t.Helper()
+
+	for {
+		for len(v.pending) == 0 {
+			_ = v.ws.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+			var e testEvent
+
+			if err := v.ws.ReadJSON(&e); err != nil {
+				v.t.Fatalf("expected %s, got error: %s", eventType, err)
+			}
+
+			if e.Type != event.TypeWindow {
+				v.pending = []*testEvent{&e}
+				break
+			}
+
+			var w struct {
+				Events []*testEvent `json:"events"`
+			}
+
+			if err := json.Unmarshal(e.Payload, &w); err != nil {
+				v.t.Fatal(err)
+			}
+
+			v.pending = w.Events
+		}
+
+		e := v.pending[0]
+		v.pending = v.pending[1:]
+
+		if e.Type == eventType {
+			return e
+		}
+	}
+}
+
+// awaitState skips playback updates until one matches
+func (v *testViewer) awaitState(matches func(s *StatePayload) bool) *StatePayload {
+	v.t.Helper()
+
+	for {
+		var s StatePayload
+
+		if err := json.Unmarshal(v.await(event.TypePlaybackUpdate).Payload, &s); err != nil {
+			v.t.Fatal(err)
+		}
+
+		if matches(&s) {
+			return &s
+		}
+	}
+}
+
+func (v *testViewer) awaitCommandError(commandType string) {
+	v.t.Helper()
+
+	var p room.CommandErrorPayload
+
+	if err := json.Unmarshal(v.await(event.TypeCommandError).Payload, &p); err != nil {
+		v.t.Fatal(err)
+	}
+
+	if p.Type != commandType {
+		v.t.Fatalf("expected an error for %s, got %+v", commandType, p)
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	v := watch(t, newTestReplay(t))
+
+	v.send(`{"type":"pause"}`)
+
+	paused := v.awaitState(func(s *StatePayload) bool {
+		return s.Paused
+	})
+
+	time.Sleep(time.Millisecond * 100)
+
+	v.send(`{"type":"resume"}`)
+
+	resumed := v.awaitState(func(s *StatePayload) bool {
+		return !s.Paused
+	})
+
+	if resumed.Tick != paused.Tick {
+		t.Fatalf("expected the playback to stay at tick %d while paused, it went on to %d", paused.Tick, resumed.Tick)
+	}
+
+	// once a second the viewer is told the position, the playback moves again
+	v.awaitState(func(s *StatePayload) bool {
+		return s.Tick > resumed.Tick
+	})
+}
+
+func TestSeek(t *testing.T) {
+	v := watch(t, newTestReplay(t))
+
+	v.send(`{"type":"pause"}`)
+	v.send(`{"type":"seek","payload":{"tick":300}}`)
+
+	v.awaitState(func(s *StatePayload) bool {
+		return s.Round == 1 && s.Tick == 300
+	})
+
+	v.send(`{"type":"seek","payload":{"tick":100}}`)
+
+	v.awaitState(func(s *StatePayload) bool {
+		return s.Round == 1 && s.Tick == 100
+	})
+
+	v.send(`{"type":"seek","payload":{"round":2,"tick":50}}`)
+
+	v.awaitState(func(s *StatePayload) bool {
+		return s.Round == 2 && s.Rounds == 2 && s.Tick == 50 && s.LastTick == testRoundTicks
+	})
+
+	v.send(`{"type":"seek","payload":{"round":3,"tick":0}}`)
+	v.awaitCommandError(CommandTypeSeek)
+
+	v.send(`{"type":"seek","payload":{"tick":-1}}`)
+	v.awaitCommandError(CommandTypeSeek)
+}
+
+func TestSpeedIsClamped(t *testing.T) {
+	tests := []struct {
+		speed float64
+		valid bool
+	}{
+		{speed: .25},
+		{speed: .5, valid: true},
+		{speed: 2, valid: true},
+		{speed: 4, valid: true},
+		{speed: 4.5},
+	}
+
+	v := watch(t, newTestReplay(t))
+
+	for _, tt := range tests {
+		payload, _ := json.Marshal(&SpeedPayload{Speed: tt.speed})
+		msg, _ := json.Marshal(&room.Command{Type: CommandTypeSpeed, Payload: payload})
+
+		v.send(string(msg))
+
+		if !tt.valid {
+			v.awaitCommandError(CommandTypeSpeed)
+			continue
+		}
+
+		v.awaitState(func(s *StatePayload) bool {
+			return s.Speed == tt.speed
+		})
+	}
+}
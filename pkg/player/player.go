@@ -3,14 +3,21 @@ package player
 import "sync"
 
 type Player struct {
-	name string
+	name  string
+	host  bool
+	ready bool
+	// team is the number of the team of the player, 0 if it is in none
+	team int
 	mu   *sync.RWMutex
 }
 
 func New(name string) *Player {
 	return &Player{
-		name: name,
-		mu:   &sync.RWMutex{},
+		name:  name,
+		host:  false,
+		ready: false,
+		team:  0,
+		mu:    &sync.RWMutex{},
 	}
 }
 
@@ -20,3 +27,45 @@ func (p *Player) GetName() string {
 
 	return p.name
 }
+
+func (p *Player) IsHost() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.host
+}
+
+func (p *Player) SetHost(host bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.host = host
+}
+
+func (p *Player) IsReady() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.ready
+}
+
+func (p *Player) SetReady(ready bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ready = ready
+}
+
+func (p *Player) GetTeam() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.team
+}
+
+func (p *Player) SetTeam(team int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.team = team
+}
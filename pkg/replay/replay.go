@@ -0,0 +1,101 @@
+package replay
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"sort"
+	"time"
+)
+
+// Replay is the record of a match, every game of it can be simulated again from the seed, rules and inputs of its round
+type Replay struct {
+	Id     string `json:"id"`
+	RoomId string `json:"roomId"`
+	// CreatedAt is the time in ms the match started
+	CreatedAt int64    `json:"createdAt"`
+	Rounds    []*Round `json:"rounds"`
+}
+
+type Round struct {
+	Number int   `json:"number"`
+	Seed   int64 `json:"seed"`
+	// Rules are the rules of the room as sent by the clients
+	Rules json.RawMessage `json:"rules"`
+	// StartAt is the time in ms tick 0 of the round began
+	StartAt int64 `json:"startAt"`
+	// CoopGroups are the ids of the games sharing a field, in the order they are stepped
+	CoopGroups [][]string `json:"coopGroups,omitempty"`
+	Games      []*Game    `json:"games"`
+}
+
+type Game struct {
+	Id         string        `json:"id"`
+	PlayerName string        `json:"playerName"`
+	Team       int           `json:"team"`
+	Inputs     []*game.Input `json:"inputs"`
+	// Checkpoints are the states of the game whenever a piece locked, they tell where a simulation went a different way
+	Checkpoints []*game.Checkpoint `json:"checkpoints"`
+	// StateHashes are the hashes of the state whenever it changed, they tell the exact tick a simulation went a different way
+	StateHashes []*game.StateHash `json:"stateHashes,omitempty"`
+	Result      *Result           `json:"result"`
+}
+
+// Result is the state of a game at the end of its round
+type Result = game.Checkpoint
+
+func New(roomId string) *Replay {
+	return &Replay{
+		Id:        uuid.NewString(),
+		RoomId:    roomId,
+		CreatedAt: time.Now().UnixMilli(),
+		Rounds:    []*Round{},
+	}
+}
+
+// NewRound records the games of a round which has ended
+func NewRound(number int, seed int64, rules json.RawMessage, startAt time.Time, games []*game.Game) *Round {
+	round := &Round{
+		Number:  number,
+		Seed:    seed,
+		Rules:   rules,
+		StartAt: startAt.UnixMilli(),
+		Games:   []*Game{},
+	}
+
+	groups := map[string][]string{}
+
+	for _, g := range games {
+		round.Games = append(round.Games, &Game{
+			Id:          g.GetId(),
+			PlayerName:  g.GetPlayer().GetName(),
+			Team:        g.GetTeam(),
+			Inputs:      g.GetInputLog(),
+			Checkpoints: g.GetCheckpoints(),
+			StateHashes: g.GetStateHashes(),
+			Result:      g.GetCheckpoint(),
+		})
+
+		if memberIds := g.GetCoopMemberIds(); len(memberIds) != 0 {
+			groups[memberIds[0]] = memberIds
+		}
+	}
+
+	sort.Slice(round.Games, func(i, j int) bool {
+		return round.Games[i].Id < round.Games[j].Id
+	})
+
+	for _, memberIds := range groups {
+		round.CoopGroups = append(round.CoopGroups, memberIds)
+	}
+
+	sort.Slice(round.CoopGroups, func(i, j int) bool {
+		return round.CoopGroups[i][0] < round.CoopGroups[j][0]
+	})
+
+	return round
+}
+
+func (r *Replay) AddRound(round *Round) {
+	r.Rounds = append(r.Rounds, round)
+}
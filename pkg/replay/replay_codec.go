@@ -0,0 +1,70 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Magic starts every replay file
+const Magic = "QDRP"
+
+// Version is the version of the replay format written, it has to be raised with every incompatible change
+const Version uint16 = 1
+
+// Encode writes the replay as magic, big endian version and the gzip compressed json document
+func Encode(w io.Writer, r *Replay) error {
+	if _, err := w.Write([]byte(Magic)); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.BigEndian, Version); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+
+	if err := json.NewEncoder(zw).Encode(r); err != nil {
+		_ = zw.Close()
+		return err
+	}
+
+	return zw.Close()
+}
+
+func Decode(rd io.Reader) (*Replay, error) {
+	magic := make([]byte, len(Magic))
+
+	if _, err := io.ReadFull(rd, magic); err != nil || !bytes.Equal(magic, []byte(Magic)) {
+		return nil, errors.New("not a replay")
+	}
+
+	var version uint16
+
+	if err := binary.Read(rd, binary.BigEndian, &version); err != nil {
+		return nil, errors.New("not a replay")
+	}
+
+	if version != Version {
+		return nil, errors.New("unsupported replay version")
+	}
+
+	zr, err := gzip.NewReader(rd)
+
+	if err != nil {
+		return nil, errors.New("malformed replay")
+	}
+
+	defer zr.Close()
+
+	var r Replay
+
+	if err := json.NewDecoder(zr).Decode(&r); err != nil {
+		return nil, errors.New("malformed replay")
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,74 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	r := New("room")
+
+	r.AddRound(&Round{
+		Number:     1,
+		Seed:       42,
+		Rules:      json.RawMessage(`{"fieldWidth":10}`),
+		StartAt:    1000,
+		CoopGroups: [][]string{{"a", "b"}},
+		Games: []*Game{
+			{
+				Id:         "a",
+				PlayerName: "player",
+				Team:       1,
+				Inputs: []*game.Input{
+					{Tick: 1, Type: game.InputTypeCommand, Command: game.CommandLeft},
+					{Tick: 20, Type: game.InputTypeGarbage, Amount: 2, SourceId: "c"},
+				},
+				Result: &Result{Tick: 300, Field: "0000000000000000", Score: 100, Lines: 1, Level: 1},
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+
+	if err := Encode(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte(Magic)) {
+		t.Fatalf("expected the replay to start with the magic")
+	}
+
+	decoded, err := Decode(bytes.NewReader(buf.Bytes()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := json.Marshal(r)
+	actual, _ := json.Marshal(decoded)
+
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestDecodeRejectsOtherVersions(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Encode(&buf, New("room")); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	data[len(Magic)+1] = byte(Version + 1)
+
+	if _, err := Decode(bytes.NewReader(data)); err == nil {
+		t.Fatalf("expected an error for version %d", Version+1)
+	}
+
+	if _, err := Decode(bytes.NewReader([]byte("nope"))); err == nil {
+		t.Fatalf("expected an error for a file without the magic")
+	}
+}
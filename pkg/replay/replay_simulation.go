@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/player"
+	"time"
+)
+
+// Simulation runs the games of a recorded round again from the seed and the inputs, tick by tick
+type Simulation struct {
+	round *Round
+	// games are in the order of the recorded games, which are sorted by id like the members of a co-op group
+	games    []*game.Game
+	inputs   map[string]map[int64][]*game.Input
+	tick     int64
+	lastTick int64
+}
+
+// NewSimulation creates headless games for the round, the config has to be the one of the round's rules
+func NewSimulation(round *Round, config *game.Config, bus *event.Bus) (*Simulation, error) {
+	s := &Simulation{
+		round:  round,
+		games:  []*game.Game{},
+		inputs: map[string]map[int64][]*game.Input{},
+	}
+
+	games := map[string]*game.Game{}
+
+	for _, rg := range round.Games {
+		p := player.New(rg.PlayerName)
+		p.SetTeam(rg.Team)
+
+		g, err := game.New(&game.Settings{
+			Id:       rg.Id,
+			EventBus: bus,
+			Player:   p,
+			Seed:     round.Seed,
+			Config:   config,
+			Headless: true,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		inputs := map[int64][]*game.Input{}
+
+		for _, input := range rg.Inputs {
+			inputs[input.Tick] = append(inputs[input.Tick], input)
+		}
+
+		s.games = append(s.games, g)
+		s.inputs[rg.Id] = inputs
+		games[rg.Id] = g
+
+		if rg.Result != nil && rg.Result.Tick > s.lastTick {
+			s.lastTick = rg.Result.Tick
+		}
+	}
+
+	startAt := time.UnixMilli(round.StartAt)
+
+	for _, memberIds := range round.CoopGroups {
+		shared := game.NewShared(config, round.Seed, memberIds)
+
+		for _, gameId := range memberIds {
+			if g, ok := games[gameId]; ok {
+				if err := g.SetShared(shared); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		shared.Start(round.Seed, startAt)
+	}
+
+	for _, g := range s.games {
+		if g.GetCoopMemberIds() == nil {
+			g.Start(round.Seed, startAt)
+		}
+	}
+
+	return s, nil
+}
+
+// Step runs the next tick of every game which has not reached its recorded end yet
+func (s *Simulation) Step() {
+	if s.IsDone() {
+		return
+	}
+
+	s.tick++
+
+	for i, g := range s.games {
+		result := s.round.Games[i].Result
+
+		if g.IsOver() || (result != nil && g.GetTick() >= result.Tick) {
+			continue
+		}
+
+		for _, input := range s.inputs[g.GetId()][s.tick] {
+			copied := *input
+			g.QueueInput(&copied)
+		}
+
+		g.Step()
+	}
+}
+
+// StepTo runs the ticks up to the tick, it cannot go back
+func (s *Simulation) StepTo(tick int64) {
+	for s.tick < tick && !s.IsDone() {
+		s.Step()
+	}
+}
+
+func (s *Simulation) GetTick() int64 {
+	return s.tick
+}
+
+// GetLastTick returns the tick the last game of the round ended at
+func (s *Simulation) GetLastTick() int64 {
+	return s.lastTick
+}
+
+func (s *Simulation) IsDone() bool {
+	return s.tick >= s.lastTick
+}
+
+func (s *Simulation) GetRound() *Round {
+	return s.round
+}
+
+// GetGames returns the games in the order of the recorded games of the round
+func (s *Simulation) GetGames() []*game.Game {
+	return s.games
+}
+
+// SetEventBus lets the games publish their updates from now on, nil silences them
+func (s *Simulation) SetEventBus(bus *event.Bus) {
+	for _, g := range s.games {
+		g.SetEventBus(bus)
+	}
+}
+
+// Stop releases the games of the simulation
+func (s *Simulation) Stop() {
+	for _, g := range s.games {
+		g.Stop()
+	}
+}
@@ -0,0 +1,189 @@
+package replay
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir is the directory replays are stored in if none is configured
+const DefaultDir = "replays"
+
+const FileExtension = ".qdrp"
+
+var ErrNotFound = errors.New("replay not found")
+
+// DefaultMaxCount is the number of replays kept if no retention is configured
+const DefaultMaxCount = 1000
+
+// DefaultMaxAge is how long replays are kept if no retention is configured
+const DefaultMaxAge = time.Hour * 24 * 30
+
+// Retention limits the replays kept in a store, the oldest are removed first. Zero keeps any number or age.
+type Retention struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+func DefaultRetention() *Retention {
+	return &Retention{
+		MaxCount: DefaultMaxCount,
+		MaxAge:   DefaultMaxAge,
+	}
+}
+
+// Store keeps replay files in a directory on the local disk, named by their id
+type Store struct {
+	dir       string
+	retention *Retention
+	mu        *sync.Mutex
+}
+
+func NewStore(dir string, retention *Retention) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+		mu:        &sync.Mutex{},
+	}
+}
+
+// GetPath returns the path of the replay file of the id, the id has to be a uuid so it cannot point outside the directory
+func (s *Store) GetPath(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", errors.New("malformed replay id")
+	}
+
+	return filepath.Join(s.dir, id+FileExtension), nil
+}
+
+// Save writes the replay to a temporary file first, so a replay is either missing or complete
+func (s *Store) Save(r *Replay) error {
+	path, err := s.GetPath(r.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, r.Id+"-*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if err := Encode(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	// the replay is saved either way, replays which could not be removed are tried again with the next save
+	if err := s.prune(); err != nil {
+		log.Printf("replay retention error: %s, ignoring.\n", err)
+	}
+
+	return nil
+}
+
+// prune removes the replays beyond the retention, the age is taken from the time the file was written
+func (s *Store) prune() error {
+	if s.retention == nil || (s.retention.MaxCount <= 0 && s.retention.MaxAge <= 0) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+
+	if err != nil {
+		return err
+	}
+
+	var files []os.FileInfo
+
+	for _, entry := range entries {
+		// temporary files of saves in progress are not replays yet
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), FileExtension) {
+			continue
+		}
+
+		if info, err := entry.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+
+	// newest first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+
+	var firstErr error
+
+	for i, info := range files {
+		keep := s.retention.MaxCount <= 0 || i < s.retention.MaxCount
+
+		if s.retention.MaxAge > 0 && time.Since(info.ModTime()) > s.retention.MaxAge {
+			keep = false
+		}
+
+		if keep {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, info.Name())); err != nil && !errors.Is(err, os.ErrNotExist) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (s *Store) Load(id string) (*Replay, error) {
+	path, err := s.GetPath(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Open(path)
+}
+
+// Open reads a replay file from any path
+func Open(path string) (*Replay, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return Decode(f)
+}
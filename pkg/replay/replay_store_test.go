@@ -0,0 +1,176 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetPathRejectsMalformedIds(t *testing.T) {
+	s := NewStore(t.TempDir(), nil)
+
+	for _, id := range []string{"", "replay", "../replay", "../../etc/passwd", "a/b", "0cf5b3a2-1c6e-4c39-8a5e-2f3f8f0e9a3c/.."} {
+		if _, err := s.GetPath(id); err == nil {
+			t.Fatalf("expected %q to be rejected", id)
+		}
+	}
+
+	id := "0cf5b3a2-1c6e-4c39-8a5e-2f3f8f0e9a3c"
+
+	path, err := s.GetPath(id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != filepath.Join(s.dir, id+FileExtension) {
+		t.Fatalf("expected the replay to be in the directory of the store, got %s", path)
+	}
+}
+
+// listDir returns the names of the files in the directory
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	s := NewStore(t.TempDir(), nil)
+	r := New("room")
+
+	r.AddRound(&Round{Number: 1, Seed: 42, Rules: json.RawMessage(`{}`), Games: []*Game{{Id: "a", PlayerName: "player"}}})
+
+	if err := s.Save(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing but the replay is left behind
+	if names := listDir(t, s.dir); len(names) != 1 || names[0] != r.Id+FileExtension {
+		t.Fatalf("expected only the replay in the directory, got %v", names)
+	}
+
+	loaded, err := s.Load(r.Id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := json.Marshal(r)
+	actual, _ := json.Marshal(loaded)
+
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+
+	if _, err := s.Load(New("room").Id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an unknown replay to be not found, got %v", err)
+	}
+}
+
+func TestFailedSaveLeavesNothingBehind(t *testing.T) {
+	s := NewStore(t.TempDir(), nil)
+	r := New("room")
+
+	path, _ := s.GetPath(r.Id)
+
+	// a directory in the place of the replay makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path, "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Save(r); err == nil {
+		t.Fatal("expected the save to fail")
+	}
+
+	if names := listDir(t, s.dir); len(names) != 1 || names[0] != r.Id+FileExtension {
+		t.Fatalf("expected no temporary file to be left, got %v", names)
+	}
+}
+
+// saveAged saves a replay and dates its file back by the age
+func saveAged(t *testing.T, s *Store, age time.Duration) *Replay {
+	r := New("room")
+
+	if err := s.Save(r); err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := s.GetPath(r.Id)
+	modTime := time.Now().Add(-age)
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention *Retention
+		ages      []time.Duration
+		kept      []bool
+	}{
+		{
+			name:      "no retention keeps everything",
+			retention: nil,
+			ages:      []time.Duration{time.Hour * 24 * 365, time.Hour, 0},
+			kept:      []bool{true, true, true},
+		},
+		{
+			name:      "the oldest replays beyond the max count are removed",
+			retention: &Retention{MaxCount: 2},
+			ages:      []time.Duration{time.Hour * 2, time.Hour * 3, time.Hour, 0},
+			kept:      []bool{false, false, true, true},
+		},
+		{
+			name:      "replays older than the max age are removed",
+			retention: &Retention{MaxAge: time.Hour},
+			ages:      []time.Duration{time.Hour * 2, time.Minute, 0},
+			kept:      []bool{false, true, true},
+		},
+		{
+			name:      "both limits apply",
+			retention: &Retention{MaxCount: 2, MaxAge: time.Hour},
+			ages:      []time.Duration{time.Hour * 2, time.Minute * 2, time.Minute, 0},
+			kept:      []bool{false, false, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(t.TempDir(), tt.retention)
+
+			var replays []*Replay
+
+			// every save prunes the replays saved before it
+			for _, age := range tt.ages {
+				replays = append(replays, saveAged(t, s, age))
+			}
+
+			for i, r := range replays {
+				_, err := s.Load(r.Id)
+
+				if kept := err == nil; kept != tt.kept[i] {
+					t.Fatalf("expected replay %d to be kept %t, got %v", i, tt.kept[i], err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package replay
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+)
+
+// Divergence is where the recording and the simulation of a game first differ.
+// Recordings with state hashes tell the exact tick, older ones only have checkpoints taken when a piece locks,
+// then the games went apart somewhere after SinceTick and up to Tick.
+type Divergence struct {
+	GameId     string
+	PlayerName string
+	// Tick is the first tick the states differ at, or the tick of the first divergent lock if the recording has no state hashes
+	Tick int64
+	// Exact is true if Tick was found by the state hashes
+	Exact bool
+	// SinceTick is the last tick both agree on, 0 if they differ from the start
+	SinceTick int64
+	// Recorded and Simulated are the checkpoints of the first divergent lock, they are nil if the state hashes differ
+	Recorded  *Result
+	Simulated *Result
+}
+
+// State is the state of a game after a tick, the checkpoint with the falling piece
+type State struct {
+	*Result
+	Piece    piece.Token
+	Rotation piece.Rotation
+	X        int
+	Y        int
+}
+
+// TickDiff is the simulated state of a game before and after a tick, together with the recorded inputs of the tick
+type TickDiff struct {
+	Inputs []*game.Input
+	Before *State
+	After  *State
+}
+
+// Verify simulates the round and compares the state hashes, checkpoints and results of every game with the recorded ones.
+// It returns the earliest divergence, nil if the simulation matches the recording.
+func Verify(round *Round, config *game.Config) (*Divergence, error) {
+	s, err := NewSimulation(round, config, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.Stop()
+
+	s.StepTo(s.GetLastTick())
+
+	var first *Divergence
+
+	for i, g := range s.GetGames() {
+		rg := round.Games[i]
+
+		var d *Divergence
+
+		if len(rg.StateHashes) != 0 {
+			d = findStateDivergence(rg.StateHashes, g.GetStateHashes())
+		}
+
+		if d == nil {
+			d = findDivergence(rg.Checkpoints, g.GetCheckpoints())
+		}
+
+		if d == nil && !isSameResult(rg.Result, g.GetCheckpoint()) {
+			d = &Divergence{
+				Tick:      g.GetTick(),
+				Recorded:  rg.Result,
+				Simulated: g.GetCheckpoint(),
+			}
+
+			if len(rg.Checkpoints) != 0 {
+				d.SinceTick = rg.Checkpoints[len(rg.Checkpoints)-1].Tick
+			}
+		}
+
+		if d != nil && (first == nil || d.Tick < first.Tick) {
+			d.GameId = rg.Id
+			d.PlayerName = rg.PlayerName
+
+			first = d
+		}
+	}
+
+	return first, nil
+}
+
+// GetTickDiff simulates the round up to the tick and returns how the tick changed the game
+func GetTickDiff(round *Round, config *game.Config, gameId string, tick int64) (*TickDiff, error) {
+	s, err := NewSimulation(round, config, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.Stop()
+
+	index := -1
+
+	for i, rg := range round.Games {
+		if rg.Id == gameId {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		return nil, errors.New("unknown game")
+	}
+
+	g := s.GetGames()[index]
+	diff := &TickDiff{}
+
+	for _, input := range round.Games[index].Inputs {
+		if input.Tick == tick {
+			diff.Inputs = append(diff.Inputs, input)
+		}
+	}
+
+	s.StepTo(tick - 1)
+	diff.Before = getState(g)
+
+	s.StepTo(tick)
+	diff.After = getState(g)
+
+	return diff, nil
+}
+
+func getState(g *game.Game) *State {
+	state := &State{
+		Result: g.GetCheckpoint(),
+	}
+
+	if fp := g.GetFallingPiece(); fp != nil {
+		if p, r, x, y := fp.GetPieceAndPosition(); p != nil {
+			state.Piece = p.Token
+			state.Rotation = r
+			state.X = x
+			state.Y = y
+		}
+	}
+
+	return state
+}
+
+// findStateDivergence compares the state hashes in order, the earlier tick of the first pair which differs is the first divergent tick
+func findStateDivergence(recorded []*game.StateHash, simulated []*game.StateHash) *Divergence {
+	for i := 0; i < len(recorded) || i < len(simulated); i++ {
+		var r, s *game.StateHash
+
+		if i < len(recorded) {
+			r = recorded[i]
+		}
+
+		if i < len(simulated) {
+			s = simulated[i]
+		}
+
+		if r != nil && s != nil && *r == *s {
+			continue
+		}
+
+		d := &Divergence{
+			Exact: true,
+		}
+
+		if r != nil && (s == nil || r.Tick < s.Tick) {
+			d.Tick = r.Tick
+		} else {
+			d.Tick = s.Tick
+		}
+
+		d.SinceTick = d.Tick - 1
+
+		return d
+	}
+
+	return nil
+}
+
+// findDivergence compares the checkpoints in order, the earlier tick of the first pair which differs is the first divergent lock
+func findDivergence(recorded []*game.Checkpoint, simulated []*game.Checkpoint) *Divergence {
+	sinceTick := int64(0)
+
+	for i := 0; i < len(recorded) || i < len(simulated); i++ {
+		var r, s *game.Checkpoint
+
+		if i < len(recorded) {
+			r = recorded[i]
+		}
+
+		if i < len(simulated) {
+			s = simulated[i]
+		}
+
+		if isSameResult(r, s) {
+			sinceTick = r.Tick
+			continue
+		}
+
+		d := &Divergence{
+			SinceTick: sinceTick,
+			Recorded:  r,
+			Simulated: s,
+		}
+
+		if r != nil && (s == nil || r.Tick < s.Tick) {
+			d.Tick = r.Tick
+		} else {
+			d.Tick = s.Tick
+		}
+
+		return d
+	}
+
+	return nil
+}
+
+func isSameResult(a *Result, b *Result) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
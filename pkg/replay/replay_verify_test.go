@@ -0,0 +1,214 @@
+package replay
+
+import (
+	"encoding/json"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/player"
+	"testing"
+	"time"
+)
+
+// recordRound plays two headless games with different inputs and records them like a room does
+func recordRound(t *testing.T, seed int64) *Round {
+	var games []*game.Game
+
+	for _, id := range []string{"a", "b"} {
+		g, err := game.New(&game.Settings{
+			Id:       id,
+			Player:   player.New(id),
+			Config:   game.DefaultConfig(),
+			Headless: true,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g.Start(seed, time.Now())
+
+		games = append(games, g)
+	}
+
+	commands := []game.Command{game.CommandLeft, game.CommandRotate, game.CommandHardLock, game.CommandRight, game.CommandHold, game.CommandHardLock}
+
+	for tick := int64(1); tick <= 1200; tick++ {
+		for i, g := range games {
+			if interval := int64(11 + i*4); tick%interval == 0 {
+				g.QueueInput(&game.Input{Type: game.InputTypeCommand, Command: commands[(tick/interval)%int64(len(commands))]})
+			}
+
+			if tick == 200 {
+				g.QueueInput(&game.Input{Type: game.InputTypeGarbage, Amount: 2, SourceId: "other"})
+			}
+
+			g.Step()
+		}
+	}
+
+	for _, g := range games {
+		g.ToggleOver(true)
+	}
+
+	return NewRound(1, seed, json.RawMessage(`{}`), time.Now(), games)
+}
+
+func TestVerify(t *testing.T) {
+	round := recordRound(t, 7)
+
+	d, err := Verify(round, game.DefaultConfig())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d != nil {
+		t.Fatalf("expected the simulation to match the recording, diverged at tick %d in game %s", d.Tick, d.GameId)
+	}
+
+	checkpoints := round.Games[1].Checkpoints
+
+	if len(checkpoints) < 3 {
+		t.Fatalf("expected at least 3 checkpoints, got %d", len(checkpoints))
+	}
+
+	checkpoints[2].Score += 100
+
+	d, err = Verify(round, game.DefaultConfig())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the state hashes still match, only the lock tells the checkpoint apart
+	if d == nil || d.Exact || d.GameId != "b" || d.Tick != checkpoints[2].Tick || d.SinceTick != checkpoints[1].Tick {
+		t.Fatalf("expected a divergence between tick %d and %d in game b, got %+v", checkpoints[1].Tick, checkpoints[2].Tick, d)
+	}
+}
+
+func TestVerifyFindsExactTick(t *testing.T) {
+	round := recordRound(t, 7)
+
+	var tampered *game.Input
+
+	for _, input := range round.Games[0].Inputs {
+		if input.Command == game.CommandHardLock && input.Tick > 100 {
+			tampered = input
+			break
+		}
+	}
+
+	if tampered == nil {
+		t.Fatal("expected a hard lock after tick 100")
+	}
+
+	tampered.Command = game.CommandHold
+
+	d, err := Verify(round, game.DefaultConfig())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d == nil || !d.Exact || d.GameId != "a" || d.Tick != tampered.Tick {
+		t.Fatalf("expected a divergence at tick %d in game a, got %+v", tampered.Tick, d)
+	}
+
+	diff, err := GetTickDiff(round, game.DefaultConfig(), d.GameId, d.Tick)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.Before.Tick != d.Tick-1 || diff.After.Tick != d.Tick {
+		t.Fatalf("expected the states of tick %d and %d, got %d and %d", d.Tick-1, d.Tick, diff.Before.Tick, diff.After.Tick)
+	}
+
+	if len(diff.Inputs) != 1 || diff.Inputs[0].Command != game.CommandHold {
+		t.Fatalf("expected the tampered input at tick %d, got %+v", d.Tick, diff.Inputs)
+	}
+}
+
+// recordCoopRound plays a co-op group on the clock like a room does, every update steps the whole group
+func recordCoopRound(t *testing.T, seed int64) *Round {
+	memberIds := []string{"a", "b"}
+	shared := game.NewShared(game.DefaultConfig(), seed, memberIds)
+
+	var games []*game.Game
+
+	for _, id := range memberIds {
+		g, err := game.New(&game.Settings{
+			Id:       id,
+			Player:   player.New(id),
+			Config:   game.DefaultConfig(),
+			Headless: true,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := g.SetShared(shared); err != nil {
+			t.Fatal(err)
+		}
+
+		games = append(games, g)
+	}
+
+	startAt := time.Now()
+
+	shared.Start(seed, startAt)
+
+	commands := []game.Command{game.CommandLeft, game.CommandRotate, game.CommandHardLock, game.CommandRight, game.CommandHardLock}
+
+	for i := 0; i < 90; i++ {
+		g := games[i%len(games)]
+
+		g.QueueInput(&game.Input{Type: game.InputTypeCommand, Command: commands[i/len(games)%len(commands)]})
+
+		time.Sleep(game.TickDuration)
+
+		g.Update()
+	}
+
+	for _, g := range games {
+		g.ToggleOver(true)
+	}
+
+	return NewRound(1, seed, json.RawMessage(`{}`), startAt, games)
+}
+
+func TestVerifyCoop(t *testing.T) {
+	round := recordCoopRound(t, 11)
+
+	if len(round.CoopGroups) != 1 {
+		t.Fatalf("expected one co-op group, got %d", len(round.CoopGroups))
+	}
+
+	d, err := Verify(round, game.DefaultConfig())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d != nil {
+		t.Fatalf("expected the simulation to match the recording, diverged at tick %d in game %s", d.Tick, d.GameId)
+	}
+
+	checkpoints := round.Games[0].Checkpoints
+
+	if len(checkpoints) < 2 {
+		t.Fatalf("expected at least 2 checkpoints, got %d", len(checkpoints))
+	}
+
+	checkpoints[1].Lines += 1
+
+	d, err = Verify(round, game.DefaultConfig())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d == nil || d.GameId != "a" || d.Tick != checkpoints[1].Tick {
+		t.Fatalf("expected a divergence at tick %d in game a, got %+v", checkpoints[1].Tick, d)
+	}
+}
@@ -0,0 +1,6 @@
+package rng
+
+// Randomizer yields elements in an order determined by its seed
+type Randomizer[ElementType any] interface {
+	NextElement() ElementType
+}
@@ -24,6 +24,11 @@ func (r *Basic) Probably(probability float64) bool {
 	return r.NextFloat64() < probability
 }
 
+// Perm returns a uniformly random permutation of the integers in [0,n)
+func (r *Basic) Perm(n int) []int {
+	return r.rand.Perm(n)
+}
+
 func (r *Basic) Shuffle(n int, swap func(i int, j int)) {
 	i := n
 
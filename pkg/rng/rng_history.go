@@ -0,0 +1,48 @@
+package rng
+
+import "math/rand"
+
+// History rerolls elements which are still in the history of recently yielded elements
+type History[ElementType comparable] struct {
+	rand     *rand.Rand
+	elements []ElementType
+	history  []ElementType
+	rerolls  int
+}
+
+func NewHistory[ElementType comparable](seed int64, elements []ElementType, initialHistory []ElementType, rerolls int) *History[ElementType] {
+	h := make([]ElementType, len(initialHistory))
+
+	copy(h, initialHistory)
+
+	return &History[ElementType]{
+		rand:     rand.New(rand.NewSource(seed)),
+		elements: elements,
+		history:  h,
+		rerolls:  rerolls,
+	}
+}
+
+func (r *History[ElementType]) isInHistory(e ElementType) bool {
+	for _, h := range r.history {
+		if h == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *History[ElementType]) NextElement() ElementType {
+	e := r.elements[r.rand.Intn(len(r.elements))]
+
+	for i := 0; i < r.rerolls && r.isInHistory(e); i++ {
+		e = r.elements[r.rand.Intn(len(r.elements))]
+	}
+
+	if len(r.history) > 0 {
+		r.history = append(r.history[1:], e)
+	}
+
+	return e
+}
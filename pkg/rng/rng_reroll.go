@@ -0,0 +1,32 @@
+package rng
+
+import "math/rand"
+
+// Reroll rolls one more than the available elements and rolls again once if it hits that or the last element
+type Reroll[ElementType comparable] struct {
+	rand     *rand.Rand
+	elements []ElementType
+	last     *ElementType
+}
+
+func NewReroll[ElementType comparable](seed int64, elements []ElementType) *Reroll[ElementType] {
+	return &Reroll[ElementType]{
+		rand:     rand.New(rand.NewSource(seed)),
+		elements: elements,
+		last:     nil,
+	}
+}
+
+func (r *Reroll[ElementType]) NextElement() ElementType {
+	i := r.rand.Intn(len(r.elements) + 1)
+
+	if i == len(r.elements) || (r.last != nil && r.elements[i] == *r.last) {
+		i = r.rand.Intn(len(r.elements))
+	}
+
+	e := r.elements[i]
+
+	r.last = &e
+
+	return e
+}
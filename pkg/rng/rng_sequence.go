@@ -0,0 +1,22 @@
+package rng
+
+// Sequence repeats a fixed sequence of elements, it ignores any seed
+type Sequence[ElementType any] struct {
+	elements []ElementType
+	index    int
+}
+
+func NewSequence[ElementType any](elements []ElementType) *Sequence[ElementType] {
+	return &Sequence[ElementType]{
+		elements: elements,
+		index:    0,
+	}
+}
+
+func (r *Sequence[ElementType]) NextElement() ElementType {
+	e := r.elements[r.index]
+
+	r.index = (r.index + 1) % len(r.elements)
+
+	return e
+}
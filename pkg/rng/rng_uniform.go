@@ -0,0 +1,20 @@
+package rng
+
+import "math/rand"
+
+// Uniform picks every element independently with the same probability
+type Uniform[ElementType any] struct {
+	rand     *rand.Rand
+	elements []ElementType
+}
+
+func NewUniform[ElementType any](seed int64, elements []ElementType) *Uniform[ElementType] {
+	return &Uniform[ElementType]{
+		rand:     rand.New(rand.NewSource(seed)),
+		elements: elements,
+	}
+}
+
+func (r *Uniform[ElementType]) NextElement() ElementType {
+	return r.elements[r.rand.Intn(len(r.elements))]
+}
@@ -3,8 +3,10 @@ package room
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/nitwhiz/quadis-server/pkg/communication"
 	"github.com/nitwhiz/quadis-server/pkg/event"
 	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
 	"github.com/nitwhiz/quadis-server/pkg/rng"
 	"sync"
 	"time"
@@ -14,27 +16,37 @@ type Room struct {
 	id                  string
 	games               map[string]*game.Game
 	gamesMutex          *sync.RWMutex
+	rulesMutex          *sync.RWMutex
+	spectators          map[string]*communication.Connection
+	spectatorsMutex     *sync.RWMutex
 	bus                 *event.Bus
 	wg                  *sync.WaitGroup
 	mu                  *sync.RWMutex
 	ctx                 context.Context
 	shutdown            context.CancelFunc
 	gamesStarted        bool
-	gameOverCount       int
+	knockouts           []*Knockout
 	createdAt           time.Time
 	randomSeed          *rng.Basic
 	rules               *Rules
 	targets             *TargetsDistribution
 	bedrockDistribution *BedrockDistribution
 	itemDistribution    *ItemDistribution
+	match               *Match
+	replays             *replay.Store
+	replay              *replay.Replay
+	roundSeed           int64
+	roundStartAt        time.Time
 }
 
 type Payload struct {
-	Id    string          `json:"id"`
-	Games []*game.Payload `json:"games"`
+	Id         string          `json:"id"`
+	Games      []*game.Payload `json:"games"`
+	Spectators int             `json:"spectators"`
 }
 
-func New() *Room {
+// New creates a room, the replays of its matches are saved to the store if there is one
+func New(rules *Rules, replays *replay.Store) *Room {
 	ctx, shutdown := context.WithCancel(context.Background())
 
 	b := event.NewBus(ctx)
@@ -45,6 +57,9 @@ func New() *Room {
 		id:               uuid.NewString(),
 		games:            map[string]*game.Game{},
 		gamesMutex:       &sync.RWMutex{},
+		rulesMutex:       &sync.RWMutex{},
+		spectators:       map[string]*communication.Connection{},
+		spectatorsMutex:  &sync.RWMutex{},
 		itemDistribution: nil,
 		bus:              b,
 		wg:               &sync.WaitGroup{},
@@ -53,24 +68,18 @@ func New() *Room {
 		shutdown:         shutdown,
 		gamesStarted:     false,
 		createdAt:        time.Now(),
-		gameOverCount:    0,
 		randomSeed:       rng.NewBasic(now.UnixMicro()),
-		rules: &Rules{
-			BedrockEnabled: true,
-			ItemsEnabled:   true,
-		},
+		rules:            rules,
+		match:            NewMatch(),
+		replays:          replays,
 	}
 
 	r.StartCurfewBouncer()
 	r.StartTargetDistribution()
 
-	if r.rules.BedrockEnabled {
-		r.StartBedrockDistribution()
-	}
-
-	if r.rules.ItemsEnabled {
-		r.StartItemDistribution(r.randomSeed.NextInt64())
-	}
+	// both distributions check the rules themselves, the host may toggle them before the start
+	r.StartBedrockDistribution()
+	r.StartItemDistribution(r.randomSeed.NextInt64())
 
 	return &r
 }
@@ -86,9 +95,45 @@ func (r *Room) ToPayload() *Payload {
 	}
 
 	return &Payload{
-		Id:    r.id,
-		Games: gps,
+		Id:         r.id,
+		Games:      gps,
+		Spectators: r.GetSpectatorsCount(),
+	}
+}
+
+// send writes an event to a single connection, bypassing the event bus
+func (r *Room) send(c *communication.Connection, eventType string, payload any) error {
+	now := time.Now().UnixMilli()
+
+	msg, err := (&event.Event{
+		Type:        eventType,
+		Origin:      event.OriginRoom(r.GetId()),
+		Payload:     payload,
+		PublishedAt: now,
+		SentAt:      now,
+	}).Serialize()
+
+	if err != nil {
+		return err
 	}
+
+	c.Write(msg)
+
+	return nil
+}
+
+func (r *Room) GetRules() *Rules {
+	r.rulesMutex.RLock()
+	defer r.rulesMutex.RUnlock()
+
+	return r.rules
+}
+
+func (r *Room) IsStarted() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.gamesStarted
 }
 
 func (r *Room) GetTargetGameId(gameId string) string {
@@ -172,22 +217,57 @@ func (r *Room) GetId() string {
 	return r.id
 }
 
-func (r *Room) Start() {
+type StartPayload struct {
+	StartAt int64 `json:"startAt"`
+}
+
+// Start starts all games after the countdown, they all begin at the same time
+func (r *Room) Start(countdown time.Duration) error {
 	r.gamesMutex.RLock()
 	defer r.gamesMutex.RUnlock()
 
+	seed := r.randomSeed.NextInt64()
+
+	groups, err := r.formCoopGroups(seed)
+
+	if err != nil {
+		return err
+	}
+
+	startAt := time.Now().Add(countdown)
+
 	r.bus.Publish(&event.Event{
 		Type:   event.TypeStart,
 		Origin: event.OriginRoom(r.id),
+		Payload: &StartPayload{
+			StartAt: startAt.UnixMilli(),
+		},
 	})
 
-	seed := r.randomSeed.NextInt64()
+	for _, shared := range groups {
+		shared.Start(seed, startAt)
+	}
 
 	for _, g := range r.games {
-		g.Start(seed)
+		if g.GetCoopMemberIds() == nil {
+			g.Start(seed, startAt)
+		}
+
+		r.match.AddPlayer(g.GetResumeToken(), g.GetTeam())
 	}
 
+	r.mu.Lock()
 	r.gamesStarted = true
+	r.knockouts = []*Knockout{}
+	r.roundSeed = seed
+	r.roundStartAt = startAt
+	r.mu.Unlock()
+
+	r.match.NextRound()
+
+	go r.publishCountdown(startAt)
+
+	return nil
 }
 
 func (r *Room) StopGames(shutdown bool) {
@@ -196,6 +276,7 @@ func (r *Room) StopGames(shutdown bool) {
 
 	for _, g := range r.games {
 		g.ToggleOver(shutdown)
+		g.GetPlayer().SetReady(false)
 	}
 
 	r.mu.Lock()
@@ -38,13 +38,18 @@ func (d *BedrockDistribution) startDistribution() {
 		case <-d.room.ctx.Done():
 			return
 		case b := <-d.Channel:
+			if rules := d.room.GetRules(); !rules.BedrockEnabled || !rules.IsVersus() {
+				break
+			}
+
 			targetGameId := d.targets.GetTargetGameId(b.SourceId)
 
 			if targetGameId != "" && targetGameId != b.SourceId {
 				d.room.gamesMutex.RLock()
 
-				if targetGame, ok := d.room.games[targetGameId]; ok {
-					targetGame.AddBedrock(b.Amount)
+				// the targets only name opponents, bedrock must not reach a teammate even if one slips through
+				if targetGame, ok := d.room.games[targetGameId]; ok && d.isOpponent(b.SourceId, targetGame) {
+					targetGame.ReceiveBedrock(b)
 
 					metrics.BedrockSentTotal.Add(float64(b.Amount))
 				}
@@ -58,3 +63,13 @@ func (d *BedrockDistribution) startDistribution() {
 		}
 	}
 }
+
+// isOpponent tells if the source of the bedrock may attack the target, bedrock of games which left goes to anyone.
+// The games have to be locked by the caller.
+func (d *BedrockDistribution) isOpponent(sourceId string, target *game.Game) bool {
+	if source, ok := d.room.games[sourceId]; ok {
+		return d.room.AreOpponents(source, target)
+	}
+
+	return true
+}
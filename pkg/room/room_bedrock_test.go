@@ -0,0 +1,43 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"testing"
+)
+
+func TestIncomingAttackUpdateCarriesPendingAmount(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.GarbageDelay = 500
+	})
+
+	srv, _ := newTestServer(t, r)
+	c, ack := join(t, srv, &HelloResponseMessage{PlayerName: "target"})
+
+	if err := r.Start(0); err != nil {
+		t.Fatal(err)
+	}
+
+	g := r.GetGame(ack.ControlledGame.Id)
+
+	g.ReceiveBedrock(&game.Bedrock{Amount: 3, SourceId: "attacker"})
+	g.ReceiveBedrock(&game.Bedrock{Amount: 2, SourceId: "attacker"})
+
+	for {
+		var p game.IncomingPayload
+
+		c.awaitPayload(event.TypeIncomingAttackUpdate, &p)
+
+		if p.Amount == 5 {
+			if len(p.Attacks) != 2 || p.Attacks[0].Amount != 3 || p.Attacks[0].SourceId != "attacker" {
+				t.Fatalf("expected both attacks to be listed, got %+v", p.Attacks)
+			}
+
+			return
+		}
+
+		if p.Amount != 3 {
+			t.Fatalf("expected 3 or 5 pending lines, got %d", p.Amount)
+		}
+	}
+}
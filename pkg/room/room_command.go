@@ -0,0 +1,128 @@
+package room
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/communication"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+)
+
+const CommandTypeSnapshot = "snapshot"
+const CommandTypeStart = "start"
+const CommandTypeKick = "kick"
+const CommandTypeTransferHost = "transfer_host"
+const CommandTypeChangeRules = "change_rules"
+const CommandTypeReady = "ready"
+const CommandTypeTarget = "target"
+const CommandTypeTeam = "team"
+
+// Command is a json encoded command sent by a player or spectator to the room
+type Command struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// GameCommandPayload is the payload of commands targeting another game
+type GameCommandPayload struct {
+	GameId string `json:"gameId"`
+}
+
+type CommandErrorPayload struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// HandleCommand runs a room command, g is nil if the command was sent by a spectator
+func (r *Room) HandleCommand(c *communication.Connection, g *game.Game, msg string) {
+	if c == nil {
+		return
+	}
+
+	cmd := Command{}
+
+	if err := json.Unmarshal([]byte(msg), &cmd); err != nil {
+		r.sendCommandError(c, &cmd, errors.New("malformed command"))
+		return
+	}
+
+	var err error
+
+	switch cmd.Type {
+	case CommandTypeSnapshot:
+		err = r.sendRoomSnapshot(c)
+		break
+	case CommandTypeStart:
+		err = r.StartAsHost(g)
+		break
+	case CommandTypeKick:
+		err = r.handleGameCommand(g, &cmd, r.KickGame)
+		break
+	case CommandTypeTransferHost:
+		err = r.handleGameCommand(g, &cmd, r.TransferHost)
+		break
+	case CommandTypeReady:
+		var p ReadyPayload
+
+		if json.Unmarshal(cmd.Payload, &p) != nil {
+			err = errors.New("malformed payload")
+		} else {
+			err = r.SetReady(g, p.Ready)
+		}
+		break
+	case CommandTypeTarget:
+		var p TargetingPayload
+
+		if json.Unmarshal(cmd.Payload, &p) != nil {
+			err = errors.New("malformed payload")
+		} else {
+			err = r.SetTargetingStrategy(g, &p)
+		}
+		break
+	case CommandTypeTeam:
+		var p TeamPayload
+
+		if json.Unmarshal(cmd.Payload, &p) != nil {
+			err = errors.New("malformed payload")
+		} else {
+			err = r.SetTeam(g, &p)
+		}
+		break
+	case CommandTypeChangeRules:
+		if g == nil || !g.IsHost() {
+			err = ErrNotHost
+		} else {
+			err = r.ChangeRules(cmd.Payload)
+		}
+		break
+	default:
+		err = errors.New("unknown command")
+		break
+	}
+
+	if err != nil {
+		r.sendCommandError(c, &cmd, err)
+	}
+}
+
+// handleGameCommand runs a host command on the game named in the payload
+func (r *Room) handleGameCommand(g *game.Game, cmd *Command, run func(gameId string) error) error {
+	if g == nil || !g.IsHost() {
+		return ErrNotHost
+	}
+
+	var p GameCommandPayload
+
+	if err := json.Unmarshal(cmd.Payload, &p); err != nil || p.GameId == "" {
+		return errors.New("malformed payload")
+	}
+
+	return run(p.GameId)
+}
+
+func (r *Room) sendCommandError(c *communication.Connection, cmd *Command, err error) {
+	_ = r.send(c, event.TypeCommandError, &CommandErrorPayload{
+		Type:  cmd.Type,
+		Error: err.Error(),
+	})
+}
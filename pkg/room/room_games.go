@@ -1,12 +1,14 @@
 package room
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/nitwhiz/quadis-server/pkg/communication"
 	"github.com/nitwhiz/quadis-server/pkg/event"
 	"github.com/nitwhiz/quadis-server/pkg/game"
 	"github.com/nitwhiz/quadis-server/pkg/player"
+	"time"
 )
 
 func (r *Room) RemoveGame(id string) {
@@ -26,6 +28,14 @@ func (r *Room) RemoveGame(id string) {
 
 		r.gamesMutex.Unlock()
 
+		if g.IsHost() {
+			g.GetPlayer().SetHost(false)
+
+			r.promoteHost()
+		}
+
+		r.endRoundIfOver()
+
 		if r.targets != nil {
 			r.targets.Randomize()
 		}
@@ -34,72 +44,126 @@ func (r *Room) RemoveGame(id string) {
 	}
 }
 
-func (r *Room) CreateGame(ws *websocket.Conn) error {
-	gameId := uuid.NewString()
-
+// Connect runs the handshake on a new websocket and adds a spectator, resumes the game of the resume token or creates a new game
+func (r *Room) Connect(ws *websocket.Conn) error {
 	c := communication.NewConnection(&communication.Settings{
 		WS:            ws,
 		ParentContext: r.ctx,
-		PreStopCallback: func() {
-			r.RemoveGame(gameId)
+		PreStopCallback: func(c *communication.Connection) {
+			r.RemoveSpectator(c)
+			r.DisconnectGame(c)
 		},
 	})
 
 	hrm, err := r.HandshakeGreeting(c)
 
+	if err == nil {
+		if hrm.Spectator {
+			err = r.addSpectator(c)
+		} else if hrm.ResumeToken != "" {
+			err = r.resumeGame(c, hrm)
+		} else {
+			err = r.createGame(c, hrm)
+		}
+	}
+
 	if err != nil {
-		return err
+		go c.Stop()
+	}
+
+	return err
+}
+
+func (r *Room) createGame(c *communication.Connection, hrm *HelloResponseMessage) error {
+	gameId := uuid.NewString()
+
+	// a player rejoining the match keeps their resume token, their round wins are counted by it
+	resumeToken := hrm.ResumeToken
+
+	if resumeToken == "" {
+		resumeToken = uuid.NewString()
 	}
 
 	gameSettings := game.Settings{
-		Id:            gameId,
-		EventBus:      r.bus,
-		Connection:    c,
-		Player:        player.New(hrm.PlayerName),
-		ParentContext: r.ctx,
-		OverCallback: func() {
-			r.mu.Lock()
-			defer r.mu.Unlock()
+		Id:             gameId,
+		EventBus:       r.bus,
+		BedrockChannel: r.bedrockDistribution.Channel,
+		Connection:     c,
+		Player:         player.New(hrm.PlayerName),
+		ParentContext:  r.ctx,
+		OverCallback: func(g *game.Game) {
+			knockedOutBy := g.GetLastAttackerId()
 
-			r.gameOverCount += 1
+			r.mu.Lock()
 
-			if r.gameOverCount >= len(r.games)-1 {
-				go r.StopGames(false)
-				go r.publishScores()
+			// stopping the games tops out the remaining ones, those do not count
+			if r.gamesStarted {
+				r.knockouts = append(r.knockouts, &Knockout{
+					GameId:       gameId,
+					KnockedOutBy: knockedOutBy,
+				})
 			}
+
+			r.mu.Unlock()
+
+			r.endRoundIfOver()
 		},
 		ActivateItemCallback: func(g *game.Game) {
 			r.itemDistribution.ActivateItem(g)
 		},
-		Seed: r.randomSeed.NextInt64(),
+		RoomCommandCallback: func(g *game.Game, cmd string) {
+			r.HandleCommand(g.GetConnection(), g, cmd)
+		},
+		Seed:        r.randomSeed.NextInt64(),
+		Config:      nil,
+		ResumeToken: resumeToken,
 	}
 
-	if r.rules.BedrockEnabled {
-		gameSettings.BedrockChannel = r.bedrockDistribution.Channel
+	r.gamesMutex.Lock()
+
+	// the rules are read while holding the games, they can only change with the games locked
+	rules := r.GetRules()
+	gameSettings.Config = rules.ToGameConfig()
+
+	if len(r.games) >= rules.MaxPlayers {
+		r.gamesMutex.Unlock()
+
+		return errors.New("room is full")
 	}
 
-	g := game.New(&gameSettings)
+	g, err := game.New(&gameSettings)
 
-	isHost := false
+	if err != nil {
+		r.gamesMutex.Unlock()
 
-	r.gamesMutex.Lock()
+		return err
+	}
 
-	// this is not 100% correct, but it's correct enough
-	isHost = len(r.games) == 0
+	if r.getHostGame() == nil {
+		g.GetPlayer().SetHost(true)
+	}
+
+	if team, ok := r.match.GetPlayerTeam(resumeToken); ok {
+		g.GetPlayer().SetTeam(team)
+	}
 
 	r.games[gameId] = g
 
 	r.gamesMutex.Unlock()
 
-	// todo: move isHost into player struct; promote new host if host leaves the game
-	err = r.HandshakeAck(c, g, isHost)
+	err = r.HandshakeAck(c, g)
 
 	if err != nil {
 		return err
 	}
 
+	// subscribe before the snapshot, so no update published in between is lost
 	r.bus.Subscribe(gameId, c)
 
+	if err := r.sendRoomSnapshot(c); err != nil {
+		return err
+	}
+
 	r.bus.Publish(&event.Event{
 		Type:    event.TypeJoin,
 		Origin:  event.OriginRoom(r.GetId()),
@@ -113,12 +177,66 @@ func (r *Room) CreateGame(ws *websocket.Conn) error {
 	return nil
 }
 
-func (r *Room) GetHostGame() *game.Game {
+func (r *Room) GetGameByResumeToken(token string) *game.Game {
 	r.gamesMutex.RLock()
 	defer r.gamesMutex.RUnlock()
 
 	for _, g := range r.games {
-		if g.IsHost() {
+		if g.GetResumeToken() == token {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// resumeGame binds a game to a new connection and sends it the full state of the room.
+// A player of the running match whose game was removed after the grace period rejoins it with a new game.
+func (r *Room) resumeGame(c *communication.Connection, hrm *HelloResponseMessage) error {
+	g := r.GetGameByResumeToken(hrm.ResumeToken)
+
+	if g == nil {
+		if _, ok := r.match.GetPlayerTeam(hrm.ResumeToken); ok && r.match.IsInProgress() {
+			return r.createGame(c, hrm)
+		}
+
+		return errors.New("unknown resume token")
+	}
+
+	oldConnection := g.GetConnection()
+
+	g.SetConnection(c)
+
+	// the old connection may not have noticed it is dead yet
+	if oldConnection != nil {
+		go oldConnection.Stop()
+	}
+
+	if err := r.HandshakeAck(c, g); err != nil {
+		return err
+	}
+
+	r.bus.Subscribe(g.GetId(), c)
+
+	if err := r.sendRoomSnapshot(c); err != nil {
+		return err
+	}
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeReconnect,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: g.ToPayload(),
+	})
+
+	return nil
+}
+
+func (r *Room) getGameByConnection(c *communication.Connection) *game.Game {
+	r.gamesMutex.RLock()
+	defer r.gamesMutex.RUnlock()
+
+	for _, g := range r.games {
+		if g.GetConnection() == c {
 			return g
 		}
 	}
@@ -126,6 +244,46 @@ func (r *Room) GetHostGame() *game.Game {
 	return nil
 }
 
+// DisconnectGame keeps the game of a lost connection running for the grace period, it is removed if it is not resumed until then
+func (r *Room) DisconnectGame(c *communication.Connection) {
+	g := r.getGameByConnection(c)
+
+	if g == nil {
+		return
+	}
+
+	gameId := g.GetId()
+	gracePeriod := r.GetRules().GetReconnectGracePeriod()
+
+	if gracePeriod == 0 {
+		r.RemoveGame(gameId)
+		return
+	}
+
+	r.bus.Unsubscribe(gameId)
+
+	g.SetConnection(nil)
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeDisconnect,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: g.ToPayload(),
+	})
+
+	disconnectedAt := g.GetDisconnectedAt()
+
+	go func() {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(gracePeriod):
+			if g.GetConnection() == nil && g.GetDisconnectedAt().Equal(disconnectedAt) {
+				r.RemoveGame(gameId)
+			}
+		}
+	}()
+}
+
 func (r *Room) GetRunningGamesCount() int {
 	r.gamesMutex.RLock()
 	defer r.gamesMutex.RUnlock()
@@ -0,0 +1,78 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"testing"
+	"time"
+)
+
+func TestResumeWithinGracePeriod(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.ReconnectGracePeriod = 30
+	})
+
+	srv, errs := newTestServer(t, r)
+	a, ackA := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	expectConnected(t, errs)
+	b, _ := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+	expectConnected(t, errs)
+
+	_ = a.ws.Close()
+
+	var disconnected game.Payload
+
+	b.awaitPayload(event.TypeDisconnect, &disconnected)
+
+	if disconnected.Id != ackA.ControlledGame.Id || disconnected.Connected {
+		t.Fatalf("expected a to be disconnected, got %+v", disconnected)
+	}
+
+	_, ack := join(t, srv, &HelloResponseMessage{ResumeToken: ackA.ResumeToken})
+
+	expectConnected(t, errs)
+
+	if ack.ControlledGame.Id != ackA.ControlledGame.Id {
+		t.Fatalf("expected to resume game %s, got %s", ackA.ControlledGame.Id, ack.ControlledGame.Id)
+	}
+
+	var reconnected game.Payload
+
+	b.awaitPayload(event.TypeReconnect, &reconnected)
+
+	if reconnected.Id != ackA.ControlledGame.Id || !reconnected.Connected {
+		t.Fatalf("expected a to be connected again, got %+v", reconnected)
+	}
+}
+
+func TestGameIsRemovedAfterGracePeriod(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.ReconnectGracePeriod = 1
+	})
+
+	srv, errs := newTestServer(t, r)
+	a, ackA := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	expectConnected(t, errs)
+	b, _ := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+	expectConnected(t, errs)
+
+	_ = a.ws.Close()
+
+	disconnectedAt := time.Now()
+
+	b.await(event.TypeDisconnect)
+
+	var left game.Payload
+
+	b.awaitPayload(event.TypeLeave, &left)
+
+	if left.Id != ackA.ControlledGame.Id || time.Since(disconnectedAt) < time.Second {
+		t.Fatalf("expected a to leave once the grace period ran out, got %+v after %s", left, time.Since(disconnectedAt))
+	}
+
+	connectTestClient(t, srv, &HelloResponseMessage{ResumeToken: ackA.ResumeToken})
+
+	if err := awaitConnectError(t, errs); err == nil {
+		t.Fatal("expected the resume token of a removed game to be rejected")
+	}
+}
@@ -13,10 +13,15 @@ type HelloAckPayload struct {
 	Room           *Payload      `json:"room"`
 	ControlledGame *game.Payload `json:"controlledGame"`
 	Host           bool          `json:"host"`
+	Rules          *Rules        `json:"rules"`
+	ResumeToken    string        `json:"resumeToken"`
+	Spectator      bool          `json:"spectator"`
 }
 
 type HelloResponseMessage struct {
-	PlayerName string `json:"playerName"`
+	PlayerName  string `json:"playerName"`
+	ResumeToken string `json:"resumeToken"`
+	Spectator   bool   `json:"spectator"`
 }
 
 func (hmr *HelloResponseMessage) Validate() bool {
@@ -60,26 +65,28 @@ func (r *Room) HandshakeGreeting(c *communication.Connection) (*HelloResponseMes
 	return &hrm, nil
 }
 
-func (r *Room) HandshakeAck(c *communication.Connection, g *game.Game, host bool) error {
-	now := time.Now().UnixMilli()
-
-	msg, err := (&event.Event{
-		Type:   event.TypeHelloAck,
-		Origin: event.OriginRoom(r.GetId()),
-		Payload: &HelloAckPayload{
-			Room:           r.ToPayload(),
-			ControlledGame: g.ToPayload(),
-			Host:           host,
-		},
-		PublishedAt: now,
-		SentAt:      now,
-	}).Serialize()
-
-	if err != nil {
-		return err
-	}
+func (r *Room) HandshakeAck(c *communication.Connection, g *game.Game) error {
+	return r.sendHelloAck(c, &HelloAckPayload{
+		Room:           r.ToPayload(),
+		ControlledGame: g.ToPayload(),
+		Host:           g.IsHost(),
+		Rules:          r.GetRules(),
+		ResumeToken:    g.GetResumeToken(),
+		Spectator:      false,
+	})
+}
 
-	c.Write(msg)
+func (r *Room) SpectatorHandshakeAck(c *communication.Connection) error {
+	return r.sendHelloAck(c, &HelloAckPayload{
+		Room:           r.ToPayload(),
+		ControlledGame: nil,
+		Host:           false,
+		Rules:          r.GetRules(),
+		ResumeToken:    "",
+		Spectator:      true,
+	})
+}
 
-	return nil
+func (r *Room) sendHelloAck(c *communication.Connection, payload *HelloAckPayload) error {
+	return r.send(c, event.TypeHelloAck, payload)
 }
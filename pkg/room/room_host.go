@@ -0,0 +1,197 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+)
+
+var ErrNotHost = errors.New("only the host can do this")
+var ErrStarted = errors.New("room has already started")
+
+type HostPayload struct {
+	Game *game.Payload `json:"game"`
+}
+
+// getHostGame returns the game of the host, the games have to be locked by the caller
+func (r *Room) getHostGame() *game.Game {
+	for _, g := range r.games {
+		if g.IsHost() {
+			return g
+		}
+	}
+
+	return nil
+}
+
+func (r *Room) GetHostGame() *game.Game {
+	r.gamesMutex.RLock()
+	defer r.gamesMutex.RUnlock()
+
+	return r.getHostGame()
+}
+
+func (r *Room) publishHostUpdate(g *game.Game) {
+	r.bus.Publish(&event.Event{
+		Type:   event.TypeHostUpdate,
+		Origin: event.OriginRoom(r.GetId()),
+		Payload: &HostPayload{
+			Game: g.ToPayload(),
+		},
+	})
+}
+
+// promoteHost makes another player the host if there is none, connected players are preferred
+func (r *Room) promoteHost() {
+	r.gamesMutex.Lock()
+
+	if r.getHostGame() != nil {
+		r.gamesMutex.Unlock()
+		return
+	}
+
+	var candidate *game.Game
+
+	for _, g := range r.games {
+		if candidate == nil || (candidate.GetConnection() == nil && g.GetConnection() != nil) {
+			candidate = g
+		}
+	}
+
+	if candidate != nil {
+		candidate.GetPlayer().SetHost(true)
+	}
+
+	r.gamesMutex.Unlock()
+
+	if candidate != nil {
+		r.publishHostUpdate(candidate)
+	}
+}
+
+func (r *Room) TransferHost(gameId string) error {
+	r.gamesMutex.Lock()
+
+	target, ok := r.games[gameId]
+
+	if !ok {
+		r.gamesMutex.Unlock()
+
+		return errors.New("unknown game")
+	}
+
+	if oldHost := r.getHostGame(); oldHost != nil {
+		oldHost.GetPlayer().SetHost(false)
+	}
+
+	target.GetPlayer().SetHost(true)
+
+	r.gamesMutex.Unlock()
+
+	r.publishHostUpdate(target)
+
+	return nil
+}
+
+// KickGame removes a game from the room and closes its connection
+func (r *Room) KickGame(gameId string) error {
+	g := r.GetGame(gameId)
+
+	if g == nil {
+		return errors.New("unknown game")
+	}
+
+	if g.IsHost() {
+		return errors.New("cannot kick the host")
+	}
+
+	c := g.GetConnection()
+
+	r.RemoveGame(gameId)
+
+	if c != nil {
+		go c.Stop()
+	}
+
+	return nil
+}
+
+// StartAsHost starts the room on behalf of g, which has to be the host
+func (r *Room) StartAsHost(g *game.Game) error {
+	if g == nil || !g.IsHost() {
+		return ErrNotHost
+	}
+
+	if r.IsStarted() || r.match.IsInProgress() {
+		return ErrStarted
+	}
+
+	if !r.hasReadyQuorum() {
+		return errors.New("not enough players are ready")
+	}
+
+	if err := r.checkTeams(); err != nil {
+		return err
+	}
+
+	r.match.Begin()
+	r.beginReplay()
+
+	if err := r.Start(r.GetRules().GetCountdown()); err != nil {
+		r.match.End()
+		return err
+	}
+
+	return nil
+}
+
+// ChangeRules applies the fields of the json document to the rules and reconfigures all games, only possible before the start
+func (r *Room) ChangeRules(data []byte) error {
+	if r.IsStarted() || r.match.IsInProgress() {
+		return ErrStarted
+	}
+
+	r.gamesMutex.Lock()
+
+	rules, err := r.GetRules().Merge(data)
+
+	if err != nil {
+		r.gamesMutex.Unlock()
+
+		return err
+	}
+
+	if len(r.games) > rules.MaxPlayers {
+		r.gamesMutex.Unlock()
+
+		return errors.New("max players below player count")
+	}
+
+	config := rules.ToGameConfig()
+
+	for _, g := range r.games {
+		if err := g.SetConfig(config); err != nil {
+			r.gamesMutex.Unlock()
+
+			return err
+		}
+
+		if g.GetTeam() > rules.Teams {
+			g.GetPlayer().SetTeam(0)
+		}
+	}
+
+	r.rulesMutex.Lock()
+	r.rules = rules
+	r.rulesMutex.Unlock()
+
+	r.gamesMutex.Unlock()
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeRulesUpdate,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: rules,
+	})
+
+	return nil
+}
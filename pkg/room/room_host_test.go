@@ -0,0 +1,102 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"testing"
+)
+
+func TestOnlyHostCanStart(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.ReadyQuorum = 0
+	})
+
+	srv, _ := newTestServer(t, r)
+	_, ackA := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	b, ackB := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+
+	if !ackA.Host || ackB.Host {
+		t.Fatalf("expected the first player to be the host, got %t and %t", ackA.Host, ackB.Host)
+	}
+
+	host := r.GetGame(ackA.ControlledGame.Id)
+	guest := r.GetGame(ackB.ControlledGame.Id)
+
+	if err := r.SetReady(guest, true); err != nil {
+		t.Fatal(err)
+	}
+
+	b.send(`{"type":"start"}`)
+
+	var cmdErr CommandErrorPayload
+
+	b.awaitPayload(event.TypeCommandError, &cmdErr)
+
+	if cmdErr.Type != CommandTypeStart || cmdErr.Error != ErrNotHost.Error() {
+		t.Fatalf("expected the start of a guest to be refused, got %+v", cmdErr)
+	}
+
+	if r.IsStarted() {
+		t.Fatal("expected the room not to start")
+	}
+
+	if err := r.StartAsHost(host); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.StartAsHost(host); !errors.Is(err, ErrStarted) {
+		t.Fatalf("expected a second start to fail, got %v", err)
+	}
+}
+
+func TestHostIsPromotedOnLeave(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.ReconnectGracePeriod = 0
+	})
+
+	srv, _ := newTestServer(t, r)
+	a, _ := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	b, ackB := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+
+	_ = a.ws.Close()
+
+	var update HostPayload
+
+	b.awaitPayload(event.TypeHostUpdate, &update)
+
+	if update.Game.Id != ackB.ControlledGame.Id {
+		t.Fatalf("expected b to become the host, got %+v", update.Game)
+	}
+
+	if host := r.GetHostGame(); host == nil || host.GetId() != ackB.ControlledGame.Id {
+		t.Fatal("expected the game of b to be the host")
+	}
+}
+
+func TestTransferHost(t *testing.T) {
+	r := newTestRoom(t, nil)
+
+	srv, _ := newTestServer(t, r)
+	a, ackA := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	b, ackB := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+
+	b.send(`{"type":"transfer_host","payload":{"gameId":"` + ackB.ControlledGame.Id + `"}}`)
+
+	var cmdErr CommandErrorPayload
+
+	b.awaitPayload(event.TypeCommandError, &cmdErr)
+
+	if cmdErr.Error != ErrNotHost.Error() {
+		t.Fatalf("expected a guest not to take the host, got %+v", cmdErr)
+	}
+
+	a.send(`{"type":"transfer_host","payload":{"gameId":"` + ackB.ControlledGame.Id + `"}}`)
+
+	var update HostPayload
+
+	b.awaitPayload(event.TypeHostUpdate, &update)
+
+	if update.Game.Id != ackB.ControlledGame.Id || r.GetGame(ackA.ControlledGame.Id).IsHost() {
+		t.Fatalf("expected the host to move to b, got %+v", update.Game)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"time"
 )
 
-const curfewActivityThreshold = time.Minute * 15
-
 func (r *Room) StartCurfewBouncer() {
 	r.NewHypervisor(&HypervisorConfig{
 		StartType: HypervisorStartTypeLazy,
@@ -14,7 +12,7 @@ func (r *Room) StartCurfewBouncer() {
 	}, func() {
 		lastRoomActivity := r.GetLastActivity()
 
-		if lastRoomActivity.Add(curfewActivityThreshold).Before(time.Now()) {
+		if lastRoomActivity.Add(r.GetRules().GetCurfewTimeout()).Before(time.Now()) {
 			rId := r.GetId()
 
 			log.Printf("stopping room %s ...\n", rId)
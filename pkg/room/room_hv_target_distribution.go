@@ -2,19 +2,23 @@ package room
 
 import (
 	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
 	"github.com/nitwhiz/quadis-server/pkg/rng"
 	"sync"
 	"time"
 )
 
 type TargetsPayload struct {
-	Targets map[string]string `json:"targets"`
+	Targets    map[string]string `json:"targets"`
+	Strategies map[string]string `json:"strategies"`
 }
 
 type TargetsDistribution struct {
 	room            *Room
 	randomGameIdBag *rng.String
 	targetMap       map[string]string
+	strategies      map[string]string
+	manualTargets   map[string]string
 	bus             *event.Bus
 	mu              *sync.RWMutex
 	random          *rng.Basic
@@ -39,6 +43,8 @@ func (r *Room) StartTargetDistribution() {
 		room:            r,
 		randomGameIdBag: rng.NewString(seed, gameIdBagGenerator),
 		targetMap:       map[string]string{},
+		strategies:      map[string]string{},
+		manualTargets:   map[string]string{},
 		bus:             r.GetEventBus(),
 		mu:              &sync.RWMutex{},
 		random:          rng.NewBasic(seed),
@@ -65,6 +71,31 @@ func (t *TargetsDistribution) GetTargetGameId(sourceGameId string) string {
 	return ""
 }
 
+func (t *TargetsDistribution) ToPayload() *TargetsPayload {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.toPayload(t.room.GetGames())
+}
+
+func (t *TargetsDistribution) toPayload(games map[string]*game.Game) *TargetsPayload {
+	targets := map[string]string{}
+	strategies := map[string]string{}
+
+	for sourceId, targetId := range t.targetMap {
+		targets[sourceId] = targetId
+	}
+
+	for gameId := range games {
+		strategies[gameId] = t.getStrategy(gameId)
+	}
+
+	return &TargetsPayload{
+		Targets:    targets,
+		Strategies: strategies,
+	}
+}
+
 func (t *TargetsDistribution) Randomize() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -75,11 +106,13 @@ func (t *TargetsDistribution) Randomize() {
 		t.deathMatchRandomizer()
 	}
 
+	games := t.room.GetGames()
+
+	t.applyStrategies(games)
+
 	t.bus.Publish(&event.Event{
-		Type:   event.TypeTargetsUpdate,
-		Origin: event.OriginRoom(t.room.GetId()),
-		Payload: &TargetsPayload{
-			Targets: t.targetMap,
-		},
+		Type:    event.TypeTargetsUpdate,
+		Origin:  event.OriginRoom(t.room.GetId()),
+		Payload: t.toPayload(games),
 	})
 }
@@ -0,0 +1,251 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"sort"
+)
+
+const StrategyRandom = "random"
+const StrategyAttackers = "attackers"
+const StrategyKOs = "kos"
+const StrategyLeader = "leader"
+const StrategyManual = "manual"
+
+// TargetingPayload is sent by players to choose their strategy, GameId is only used by the manual strategy
+type TargetingPayload struct {
+	Strategy string `json:"strategy"`
+	GameId   string `json:"gameId"`
+}
+
+func (r *Room) SetTargetingStrategy(g *game.Game, p *TargetingPayload) error {
+	if g == nil {
+		return errors.New("spectators cannot target")
+	}
+
+	if p.Strategy == StrategyManual {
+		if target := r.GetGame(p.GameId); target == nil || !r.AreOpponents(g, target) {
+			return errors.New("unknown target")
+		}
+	}
+
+	if err := r.targets.SetStrategy(g.GetId(), p.Strategy, p.GameId); err != nil {
+		return err
+	}
+
+	// only the target of the caller follows the new strategy, everyone else keeps theirs
+	r.targets.Retarget(g.GetId())
+
+	return nil
+}
+
+func (t *TargetsDistribution) SetStrategy(gameId string, strategy string, targetId string) error {
+	switch strategy {
+	case StrategyRandom, StrategyAttackers, StrategyKOs, StrategyLeader, StrategyManual:
+		break
+	default:
+		return errors.New("unknown strategy")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.strategies[gameId] = strategy
+
+	if strategy == StrategyManual {
+		t.manualTargets[gameId] = targetId
+	} else {
+		delete(t.manualTargets, gameId)
+	}
+
+	return nil
+}
+
+func (t *TargetsDistribution) getStrategy(gameId string) string {
+	if strategy, ok := t.strategies[gameId]; ok {
+		return strategy
+	}
+
+	return StrategyRandom
+}
+
+// getHighestStackId returns the opponent closest to topping out
+func (t *TargetsDistribution) getHighestStackId(games map[string]*game.Game, sourceId string) string {
+	targetId := ""
+	targetHeight := -1
+
+	for gameId, g := range games {
+		if !t.room.AreOpponents(games[sourceId], g) {
+			continue
+		}
+
+		if height := g.GetField().GetStackHeight(); height > targetHeight {
+			targetId = gameId
+			targetHeight = height
+		}
+	}
+
+	return targetId
+}
+
+// getLeaderId returns the opponent with the highest score
+func (t *TargetsDistribution) getLeaderId(games map[string]*game.Game, sourceId string) string {
+	targetId := ""
+	targetScore := -1
+
+	for gameId, g := range games {
+		if !t.room.AreOpponents(games[sourceId], g) {
+			continue
+		}
+
+		if s := g.GetScore().ToPayload().Score; s > targetScore {
+			targetId = gameId
+			targetScore = s
+		}
+	}
+
+	return targetId
+}
+
+// getStrategyTargetId returns the target the strategy of the game picks, empty if it keeps its random target
+func (t *TargetsDistribution) getStrategyTargetId(running map[string]*game.Game, gameId string) string {
+	switch t.getStrategy(gameId) {
+	case StrategyKOs:
+		return t.getHighestStackId(running, gameId)
+	case StrategyLeader:
+		return t.getLeaderId(running, gameId)
+	case StrategyManual:
+		if target, ok := running[t.manualTargets[gameId]]; ok && t.room.AreOpponents(running[gameId], target) {
+			return t.manualTargets[gameId]
+		}
+
+		return ""
+	case StrategyAttackers:
+		var attackerIds []string
+
+		for sourceId, targetId := range t.targetMap {
+			if targetId == gameId && sourceId != gameId {
+				attackerIds = append(attackerIds, sourceId)
+			}
+		}
+
+		if len(attackerIds) == 0 {
+			return ""
+		}
+
+		// the order of the map is random, the pick has to come from the seeded random
+		sort.Strings(attackerIds)
+
+		return attackerIds[t.random.NextInt64()%int64(len(attackerIds))]
+	default:
+		return ""
+	}
+}
+
+func getRunningGames(games map[string]*game.Game) map[string]*game.Game {
+	running := map[string]*game.Game{}
+
+	for gameId, g := range games {
+		if !g.IsOver() {
+			running[gameId] = g
+		}
+	}
+
+	return running
+}
+
+// applyStrategies replaces the random targets of the games which chose another strategy
+func (t *TargetsDistribution) applyStrategies(games map[string]*game.Game) {
+	for gameId := range t.strategies {
+		if _, ok := games[gameId]; !ok {
+			delete(t.strategies, gameId)
+			delete(t.manualTargets, gameId)
+		}
+	}
+
+	running := getRunningGames(games)
+
+	if len(running) <= 1 {
+		return
+	}
+
+	t.replaceTeammateTargets(running)
+
+	for gameId, strategy := range t.strategies {
+		if _, ok := running[gameId]; !ok || strategy == StrategyAttackers {
+			continue
+		}
+
+		if targetId := t.getStrategyTargetId(running, gameId); targetId != "" && targetId != gameId {
+			t.targetMap[gameId] = targetId
+		}
+	}
+
+	// attackers are resolved last since they depend on everyone else's targets
+	attackerTargets := map[string]string{}
+
+	for gameId, strategy := range t.strategies {
+		if _, ok := running[gameId]; !ok || strategy != StrategyAttackers {
+			continue
+		}
+
+		if targetId := t.getStrategyTargetId(running, gameId); targetId != "" {
+			attackerTargets[gameId] = targetId
+		}
+	}
+
+	for gameId, targetId := range attackerTargets {
+		t.targetMap[gameId] = targetId
+	}
+}
+
+// Retarget picks a new target for a single game by its strategy and leaves the targets of all other games alone
+func (t *TargetsDistribution) Retarget(gameId string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	games := t.room.GetGames()
+	running := getRunningGames(games)
+
+	if _, ok := running[gameId]; ok && len(running) > 1 {
+		if targetId := t.getStrategyTargetId(running, gameId); targetId != "" && targetId != gameId {
+			t.targetMap[gameId] = targetId
+		}
+	}
+
+	t.bus.Publish(&event.Event{
+		Type:    event.TypeTargetsUpdate,
+		Origin:  event.OriginRoom(t.room.GetId()),
+		Payload: t.toPayload(games),
+	})
+}
+
+// replaceTeammateTargets retargets games which got a teammate assigned to a random opponent, or to themselves if there is none
+func (t *TargetsDistribution) replaceTeammateTargets(games map[string]*game.Game) {
+	if !t.room.GetRules().IsTeamMode() {
+		return
+	}
+
+	for sourceId, targetId := range t.targetMap {
+		source, target := games[sourceId], games[targetId]
+
+		if source == nil || target == nil || sourceId == targetId || t.room.AreOpponents(source, target) {
+			continue
+		}
+
+		var opponentIds []string
+
+		for gameId, g := range games {
+			if t.room.AreOpponents(source, g) {
+				opponentIds = append(opponentIds, gameId)
+			}
+		}
+
+		if len(opponentIds) == 0 {
+			t.targetMap[sourceId] = sourceId
+		} else {
+			t.targetMap[sourceId] = opponentIds[t.random.NextInt64()%int64(len(opponentIds))]
+		}
+	}
+}
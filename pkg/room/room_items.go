@@ -19,6 +19,7 @@ type ItemDistribution struct {
 	room          *Room
 	mu            *sync.RWMutex
 	gameItems     map[string]*item.Item
+	affections    map[string]string
 	itemGenerator *item.Generator
 	random        *rng.Basic
 }
@@ -32,6 +33,7 @@ func (r *Room) StartItemDistribution(seed int64) {
 		room:          r,
 		mu:            &sync.RWMutex{},
 		gameItems:     map[string]*item.Item{},
+		affections:    map[string]string{},
 		itemGenerator: item.NewGenerator(seed),
 		random:        rng.NewBasic(seed),
 	}
@@ -46,6 +48,16 @@ func (r *Room) UpdateItemAffection(gameId string, itemType string) {
 }
 
 func (i *ItemDistribution) UpdateItemAffection(gameId string, itemType string) {
+	i.mu.Lock()
+
+	if itemType == item.TypeNone {
+		delete(i.affections, gameId)
+	} else {
+		i.affections[gameId] = itemType
+	}
+
+	i.mu.Unlock()
+
 	i.room.bus.Publish(&event.Event{
 		Type:   event.TypeItemAffectionUpdate,
 		Origin: event.OriginGame(gameId),
@@ -55,6 +67,31 @@ func (i *ItemDistribution) UpdateItemAffection(gameId string, itemType string) {
 	})
 }
 
+// GetItemType returns the type of the item the game is holding, nil if it holds none
+func (i *ItemDistribution) GetItemType(gameId string) *string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if gameItem, ok := i.gameItems[gameId]; ok && gameItem != nil {
+		itemType := gameItem.Type
+		return &itemType
+	}
+
+	return nil
+}
+
+// GetAffection returns the type of the item currently affecting the game
+func (i *ItemDistribution) GetAffection(gameId string) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if itemType, ok := i.affections[gameId]; ok {
+		return itemType
+	}
+
+	return item.TypeNone
+}
+
 func (i *ItemDistribution) ActivateItem(sourceGame *game.Game) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -79,6 +116,10 @@ func (i *ItemDistribution) ActivateItem(sourceGame *game.Game) {
 }
 
 func (i *ItemDistribution) randomize() {
+	if rules := i.room.GetRules(); !rules.ItemsEnabled || !rules.IsVersus() {
+		return
+	}
+
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
@@ -89,7 +130,7 @@ func (i *ItemDistribution) randomize() {
 		if gItem, ok := i.gameItems[gId]; !ok || gItem == nil {
 			newItem := i.itemGenerator.NextElement()
 
-			if i.random.Probably(.75) {
+			if i.random.Probably(i.room.GetRules().ItemDropProbability) {
 				i.gameItems[gId] = newItem
 
 				i.room.bus.Publish(&event.Event{
@@ -112,7 +153,7 @@ func (i *ItemDistribution) startDistribution() {
 		select {
 		case <-i.room.ctx.Done():
 			return
-		case <-time.After(time.Second * 10):
+		case <-time.After(i.room.GetRules().GetItemInterval()):
 			i.randomize()
 		}
 	}
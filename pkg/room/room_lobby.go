@@ -0,0 +1,87 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"math"
+	"time"
+)
+
+type ReadyPayload struct {
+	Ready bool `json:"ready"`
+}
+
+type CountdownPayload struct {
+	Remaining int   `json:"remaining"`
+	StartAt   int64 `json:"startAt"`
+}
+
+func (r *Room) SetReady(g *game.Game, ready bool) error {
+	if g == nil {
+		return errors.New("spectators cannot be ready")
+	}
+
+	if r.IsStarted() || r.match.IsInProgress() {
+		return ErrStarted
+	}
+
+	g.GetPlayer().SetReady(ready)
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeReadyUpdate,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: g.ToPayload(),
+	})
+
+	return nil
+}
+
+// hasReadyQuorum checks if the share of ready players reaches the quorum of the rules
+func (r *Room) hasReadyQuorum() bool {
+	r.gamesMutex.RLock()
+	defer r.gamesMutex.RUnlock()
+
+	readyCount := 0
+
+	for _, g := range r.games {
+		if g.GetPlayer().IsReady() {
+			readyCount += 1
+		}
+	}
+
+	required := int(math.Ceil(r.GetRules().ReadyQuorum * float64(len(r.games))))
+
+	return readyCount >= required && readyCount > 0
+}
+
+// publishCountdown publishes the remaining seconds until startAt, once per second
+func (r *Room) publishCountdown(startAt time.Time) {
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	for {
+		remaining := time.Until(startAt)
+		seconds := int(math.Ceil(remaining.Seconds()))
+
+		if seconds <= 0 {
+			return
+		}
+
+		r.bus.Publish(&event.Event{
+			Type:   event.TypeCountdown,
+			Origin: event.OriginRoom(r.GetId()),
+			Payload: &CountdownPayload{
+				Remaining: seconds,
+				StartAt:   startAt.UnixMilli(),
+			},
+		})
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(remaining - time.Second*time.Duration(seconds-1)):
+			break
+		}
+	}
+}
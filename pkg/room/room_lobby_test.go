@@ -0,0 +1,101 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/player"
+	"strconv"
+	"testing"
+)
+
+// addHeadlessGames adds games without connections to the room, the first one is the host
+func addHeadlessGames(t *testing.T, r *Room, count int) []*game.Game {
+	var games []*game.Game
+
+	r.gamesMutex.Lock()
+	defer r.gamesMutex.Unlock()
+
+	for i := 0; i < count; i++ {
+		id := strconv.Itoa(i)
+
+		g, err := game.New(&game.Settings{
+			Id:       id,
+			Player:   player.New(id),
+			Config:   r.GetRules().ToGameConfig(),
+			Headless: true,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g.GetPlayer().SetHost(i == 0)
+
+		r.games[id] = g
+		games = append(games, g)
+	}
+
+	return games
+}
+
+func TestReadyQuorum(t *testing.T) {
+	tests := []struct {
+		quorum  float64
+		players int
+		ready   int
+		reached bool
+	}{
+		{quorum: 1, players: 3, ready: 2, reached: false},
+		{quorum: 1, players: 3, ready: 3, reached: true},
+		{quorum: .5, players: 4, ready: 1, reached: false},
+		{quorum: .5, players: 4, ready: 2, reached: true},
+		{quorum: .5, players: 3, ready: 1, reached: false},
+		{quorum: .5, players: 3, ready: 2, reached: true},
+		{quorum: 0, players: 3, ready: 0, reached: false},
+		{quorum: 0, players: 3, ready: 1, reached: true},
+	}
+
+	for _, tt := range tests {
+		r := newTestRoom(t, func(rules *Rules) {
+			rules.ReadyQuorum = tt.quorum
+		})
+
+		games := addHeadlessGames(t, r, tt.players)
+
+		for _, g := range games[:tt.ready] {
+			if err := r.SetReady(g, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if reached := r.hasReadyQuorum(); reached != tt.reached {
+			t.Errorf("expected the quorum %.1f to be reached %t with %d of %d players ready", tt.quorum, tt.reached, tt.ready, tt.players)
+		}
+	}
+}
+
+func TestStartNeedsReadyQuorum(t *testing.T) {
+	r := newTestRoom(t, nil)
+
+	games := addHeadlessGames(t, r, 2)
+
+	if err := r.SetReady(games[0], true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.StartAsHost(games[0]); err == nil {
+		t.Fatal("expected the start to wait for every player to be ready")
+	}
+
+	if err := r.SetReady(games[1], true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.StartAsHost(games[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetReady(games[1], false); !errors.Is(err, ErrStarted) {
+		t.Fatalf("expected the readiness to be fixed once started, got %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Match keeps track of the rounds of a best-of-n match and the round wins per player, or per team number in team mode.
+// Players are known by the resume token of their game, so they keep their round wins when they rejoin with it.
+type Match struct {
+	inProgress bool
+	round      int
+	points     map[string]int
+	// players maps the resume tokens of everyone who played a round of the match to their team
+	players map[string]int
+	mu      *sync.RWMutex
+}
+
+type MatchPayload struct {
+	InProgress bool           `json:"inProgress"`
+	Round      int            `json:"round"`
+	Points     map[string]int `json:"points"`
+}
+
+type RoundResultPayload struct {
+	Round    int            `json:"round"`
+	WinnerId string         `json:"winnerId"`
+	Points   map[string]int `json:"points"`
+	// NextRoundAt is the time the next round starts, 0 if the match is over
+	NextRoundAt int64 `json:"nextRoundAt"`
+}
+
+type MatchResultPayload struct {
+	Rounds   int            `json:"rounds"`
+	WinnerId string         `json:"winnerId"`
+	Points   map[string]int `json:"points"`
+}
+
+func NewMatch() *Match {
+	return &Match{
+		inProgress: false,
+		round:      0,
+		points:     map[string]int{},
+		players:    map[string]int{},
+		mu:         &sync.RWMutex{},
+	}
+}
+
+func (m *Match) Begin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inProgress = true
+	m.round = 0
+	m.points = map[string]int{}
+	m.players = map[string]int{}
+}
+
+func (m *Match) End() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inProgress = false
+}
+
+func (m *Match) IsInProgress() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.inProgress
+}
+
+func (m *Match) NextRound() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.round += 1
+}
+
+func (m *Match) GetRound() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.round
+}
+
+// AddPlayer remembers a player of the match by their resume token
+func (m *Match) AddPlayer(resumeToken string, team int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.players[resumeToken] = team
+}
+
+// GetPlayerTeam returns the team of a player of the match, false if the resume token did not play in the match
+func (m *Match) GetPlayerTeam(resumeToken string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	team, ok := m.players[resumeToken]
+
+	return team, ok
+}
+
+// AddRoundWinner awards a round point, an empty key means nobody won the round
+func (m *Match) AddRoundWinner(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if key != "" {
+		m.points[key] += 1
+	}
+}
+
+// GetWinner returns the key of the player or team which won the match, empty if the match is not decided yet
+func (m *Match) GetWinner(rules *Rules) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	leaderId := ""
+	leaderPoints := 0
+	tied := false
+
+	for key, points := range m.points {
+		if points > leaderPoints {
+			leaderId = key
+			leaderPoints = points
+			tied = false
+		} else if points == leaderPoints {
+			tied = true
+		}
+	}
+
+	if leaderPoints >= rules.GetRoundsToWin() {
+		return leaderId
+	}
+
+	// the rounds are used up, the most round wins take the match unless there is a tie
+	if m.round >= rules.BestOf && !tied {
+		return leaderId
+	}
+
+	return ""
+}
+
+func (m *Match) ToPayload() *MatchPayload {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return &MatchPayload{
+		InProgress: m.inProgress,
+		Round:      m.round,
+		Points:     m.getPoints(),
+	}
+}
+
+func (m *Match) getPoints() map[string]int {
+	points := map[string]int{}
+
+	for key, p := range m.points {
+		points[key] = p
+	}
+
+	return points
+}
+
+func (m *Match) GetPoints() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.getPoints()
+}
+
+// getRoundWinnerKey returns the match key of the last game standing, in team mode the number of the last team standing, empty if there is none
+func (r *Room) getRoundWinnerKey() string {
+	teamMode := r.GetRules().IsTeamMode()
+
+	r.gamesMutex.RLock()
+	defer r.gamesMutex.RUnlock()
+
+	for _, g := range r.games {
+		if !g.IsOver() {
+			if teamMode {
+				return strconv.Itoa(g.GetTeam())
+			}
+
+			return g.GetResumeToken()
+		}
+	}
+
+	return ""
+}
+
+// getMatchId returns the id a match key is shown as, the id of the game of the player or the team number in team mode.
+// It is empty for players which are not in the room, the resume tokens are never shown.
+func (r *Room) getMatchId(key string) string {
+	if key == "" || r.GetRules().IsTeamMode() {
+		return key
+	}
+
+	if g := r.GetGameByResumeToken(key); g != nil {
+		return g.GetId()
+	}
+
+	return ""
+}
+
+// toMatchIds keys the match points by the ids they are shown as, players not in the room are left out
+func (r *Room) toMatchIds(points map[string]int) map[string]int {
+	shown := map[string]int{}
+
+	for key, p := range points {
+		if id := r.getMatchId(key); id != "" {
+			shown[id] = p
+		}
+	}
+
+	return shown
+}
+
+func (r *Room) getMatchPayload() *MatchPayload {
+	p := r.match.ToPayload()
+	p.Points = r.toMatchIds(p.Points)
+
+	return p
+}
+
+// isRoundOver checks whether at most one player, or one team in team mode, is still standing.
+// Single player modes are over when every game is.
+func (r *Room) isRoundOver() bool {
+	rules := r.GetRules()
+	teamMode := rules.IsTeamMode()
+	standing := map[int]bool{}
+	runningCount := 0
+
+	for _, g := range r.GetGames() {
+		if !g.IsOver() {
+			runningCount += 1
+			standing[g.GetTeam()] = true
+		}
+	}
+
+	// in single player modes everyone plays until their own game is over
+	if game.IsSinglePlayerMode(rules.Mode) {
+		return runningCount == 0
+	}
+
+	if teamMode {
+		return len(standing) <= 1
+	}
+
+	return runningCount <= 1
+}
+
+// endRoundIfOver ends the running round once it is decided
+func (r *Room) endRoundIfOver() {
+	roundOver := r.isRoundOver()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.gamesStarted || !roundOver {
+		return
+	}
+
+	r.gamesStarted = false
+
+	go r.endRound()
+}
+
+// endRound stops the games and either starts the next round after the intermission or ends the match
+func (r *Room) endRound() {
+	winnerKey := r.getRoundWinnerKey()
+	winnerId := r.getMatchId(winnerKey)
+
+	r.StopGames(false)
+	r.recordRound()
+	r.publishScores()
+
+	rules := r.GetRules()
+
+	if game.IsSinglePlayerMode(rules.Mode) {
+		r.publishModeResults()
+		r.match.End()
+		r.saveReplay()
+
+		return
+	}
+
+	r.match.AddRoundWinner(winnerKey)
+
+	round := r.match.GetRound()
+	matchWinnerKey := r.match.GetWinner(rules)
+	matchOver := !r.match.IsInProgress() || matchWinnerKey != "" || round >= rules.BestOf
+
+	nextRoundAt := int64(0)
+
+	if !matchOver {
+		nextRoundAt = time.Now().Add(rules.GetIntermission()).UnixMilli()
+	}
+
+	r.bus.Publish(&event.Event{
+		Type:   event.TypeRoundResult,
+		Origin: event.OriginRoom(r.GetId()),
+		Payload: &RoundResultPayload{
+			Round:       round,
+			WinnerId:    winnerId,
+			Points:      r.toMatchIds(r.match.GetPoints()),
+			NextRoundAt: nextRoundAt,
+		},
+	})
+
+	if matchOver {
+		r.match.End()
+
+		r.bus.Publish(&event.Event{
+			Type:   event.TypeMatchResult,
+			Origin: event.OriginRoom(r.GetId()),
+			Payload: &MatchResultPayload{
+				Rounds:   round,
+				WinnerId: r.getMatchId(matchWinnerKey),
+				Points:   r.toMatchIds(r.match.GetPoints()),
+			},
+		})
+
+		r.saveReplay()
+
+		return
+	}
+
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	select {
+	case <-r.ctx.Done():
+		return
+	case <-time.After(rules.GetIntermission()):
+		if err := r.Start(rules.GetCountdown()); err != nil {
+			log.Printf("round error: %s, ending the match.\n", err)
+
+			r.match.End()
+			r.saveReplay()
+		}
+	}
+}
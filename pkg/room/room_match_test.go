@@ -0,0 +1,75 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"testing"
+)
+
+func TestRejoinKeepsMatchPoints(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.BestOf = 3
+		rules.Intermission = 60
+		rules.ReconnectGracePeriod = 0
+	})
+
+	srv, _ := newTestServer(t, r)
+	a, ackA := join(t, srv, &HelloResponseMessage{PlayerName: "a"})
+	b, ackB := join(t, srv, &HelloResponseMessage{PlayerName: "b"})
+
+	host := r.GetGame(ackA.ControlledGame.Id)
+
+	for _, g := range r.GetGames() {
+		if err := r.SetReady(g, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.StartAsHost(host); err != nil {
+		t.Fatal(err)
+	}
+
+	r.GetGame(ackB.ControlledGame.Id).ToggleOver(false)
+
+	var result RoundResultPayload
+
+	a.awaitPayload(event.TypeRoundResult, &result)
+
+	if result.WinnerId != ackA.ControlledGame.Id || result.Points[ackA.ControlledGame.Id] != 1 {
+		t.Fatalf("expected a to win the first round, got %+v", result)
+	}
+
+	// a loses the connection and the grace period runs out during the intermission
+	_ = a.ws.Close()
+
+	b.await(event.TypeLeave)
+
+	rejoined, ack := join(t, srv, &HelloResponseMessage{PlayerName: "a", ResumeToken: ackA.ResumeToken})
+
+	if ack.ControlledGame.Id == ackA.ControlledGame.Id || ack.ResumeToken != ackA.ResumeToken {
+		t.Fatalf("expected a new game with the same resume token, got %+v", ack.ControlledGame)
+	}
+
+	var snapshot SnapshotPayload
+
+	rejoined.awaitPayload(event.TypeRoomSnapshot, &snapshot)
+
+	if points := snapshot.Match.Points[ack.ControlledGame.Id]; points != 1 {
+		t.Fatalf("expected the round win to be kept after the rejoin, got %+v", snapshot.Match.Points)
+	}
+
+	if _, ok := snapshot.Match.Points[ackA.ResumeToken]; ok {
+		t.Fatal("expected the resume token not to be shown")
+	}
+}
+
+func TestRejoinNeedsMatchPlayer(t *testing.T) {
+	r := newTestRoom(t, nil)
+
+	srv, errs := newTestServer(t, r)
+
+	connectTestClient(t, srv, &HelloResponseMessage{PlayerName: "a", ResumeToken: "made-up"})
+
+	if err := awaitConnectError(t, errs); err == nil {
+		t.Fatal("expected an unknown resume token to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"sort"
+)
+
+type modeResultPayload struct {
+	*game.ResultPayload
+	Placement int `json:"placement"`
+}
+
+// isResultBetter ranks the results of a single player mode
+func isResultBetter(mode string, a *game.ResultPayload, b *game.ResultPayload) bool {
+	switch mode {
+	case game.ModeSprint:
+		if a.Completed != b.Completed {
+			return a.Completed
+		}
+
+		if a.Completed {
+			return a.Time < b.Time
+		}
+
+		return a.Lines > b.Lines
+	case game.ModeMarathon:
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+
+		return a.Score > b.Score
+	default:
+		return a.Score > b.Score
+	}
+}
+
+func (r *Room) publishModeResults() {
+	mode := r.GetRules().Mode
+
+	var results []*modeResultPayload
+
+	for _, g := range r.GetGames() {
+		results = append(results, &modeResultPayload{
+			ResultPayload: g.GetResult(),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return isResultBetter(mode, results[i].ResultPayload, results[j].ResultPayload)
+	})
+
+	for i, res := range results {
+		res.Placement = i + 1
+	}
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeModeResult,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: results,
+	})
+}
@@ -0,0 +1,111 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/attack"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+	"time"
+)
+
+func TestSprintRanking(t *testing.T) {
+	fast := &game.ResultPayload{Completed: true, Time: 60000, Lines: 40}
+	slow := &game.ResultPayload{Completed: true, Time: 90000, Lines: 40}
+	toppedOut := &game.ResultPayload{Completed: false, Time: 30000, Lines: 39}
+	fewerLines := &game.ResultPayload{Completed: false, Time: 20000, Lines: 10}
+
+	ranked := []*game.ResultPayload{fast, slow, toppedOut, fewerLines}
+
+	for i := range ranked {
+		for j := range ranked {
+			if got, want := isResultBetter(game.ModeSprint, ranked[i], ranked[j]), i < j; got != want {
+				t.Errorf("expected isResultBetter(%d, %d) to be %t", i, j, want)
+			}
+		}
+	}
+}
+
+func TestMarathonRanking(t *testing.T) {
+	moreLines := &game.ResultPayload{Lines: 150, Score: 1000}
+	moreScore := &game.ResultPayload{Lines: 100, Score: 5000}
+	lessScore := &game.ResultPayload{Lines: 100, Score: 4000}
+
+	ranked := []*game.ResultPayload{moreLines, moreScore, lessScore}
+
+	for i := range ranked {
+		for j := range ranked {
+			if got, want := isResultBetter(game.ModeMarathon, ranked[i], ranked[j]), i < j; got != want {
+				t.Errorf("expected isResultBetter(%d, %d) to be %t", i, j, want)
+			}
+		}
+	}
+}
+
+func TestSprintEndReportsCommandTime(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Mode = game.ModeSprint
+		rules.Randomizer = piece.RandomizerSequence
+		rules.RandomizerSequence = "I"
+	})
+
+	srv, _ := newTestServer(t, r)
+	c, ack := join(t, srv, &HelloResponseMessage{PlayerName: "sprinter"})
+	g := r.GetGame(ack.ControlledGame.Id)
+
+	if err := r.Start(0); err != nil {
+		t.Fatal(err)
+	}
+
+	for g.GetTick() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// everything but the columns of the falling I is filled, so its hard drop clears the last line of the sprint
+	f := g.GetField()
+	_, _, x, _ := g.GetFallingPiece().GetPieceAndPosition()
+
+	for column := 0; column < f.GetWidth(); column++ {
+		if column < x || column >= x+4 {
+			f.PutPiece(&piece.I, 1, column-2, f.GetHeight()-4)
+		}
+	}
+
+	g.GetScore().AddClear(&attack.Clear{Lines: 39})
+	c.send(string(game.CommandHardLock))
+
+	var results []*modeResultPayload
+
+	c.awaitPayload(event.TypeModeResult, &results)
+
+	if len(results) != 1 || !results[0].Completed {
+		t.Fatalf("expected the sprint to be completed, got %+v", results)
+	}
+
+	var finish *game.Input
+
+	for _, input := range g.GetInputLog() {
+		if input.Command == game.CommandHardLock {
+			finish = input
+		}
+	}
+
+	if finish == nil {
+		t.Fatal("expected the hard lock to be logged")
+	}
+
+	// a command received while its tick was already running late is timed at the end of the tick
+	want := finish.Time
+	start := (finish.Tick - 1) * 1000 / game.TicksPerSecond
+	end := finish.Tick * 1000 / game.TicksPerSecond
+
+	if want < start {
+		want = start
+	} else if want > end {
+		want = end
+	}
+
+	if results[0].Time != want {
+		t.Fatalf("expected the sprint to be timed by the hard lock at %d ms, got %d ms", want, results[0].Time)
+	}
+}
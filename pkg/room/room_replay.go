@@ -0,0 +1,73 @@
+package room
+
+import (
+	"encoding/json"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
+	"log"
+)
+
+type ReplayPayload struct {
+	Id string `json:"id"`
+}
+
+// beginReplay starts recording a new match
+func (r *Room) beginReplay() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replay = replay.New(r.id)
+}
+
+// recordRound adds the round which just ended to the replay, the games have to be stopped
+func (r *Room) recordRound() {
+	rulesJson, err := json.Marshal(r.GetRules())
+
+	if err != nil {
+		log.Printf("replay error: %s, ignoring.\n", err)
+		return
+	}
+
+	var games []*game.Game
+
+	for _, g := range r.GetGames() {
+		games = append(games, g)
+	}
+
+	r.mu.RLock()
+	round := replay.NewRound(r.match.GetRound(), r.roundSeed, rulesJson, r.roundStartAt, games)
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.replay != nil {
+		r.replay.AddRound(round)
+	}
+}
+
+// saveReplay writes the replay of the match which just ended and tells the room where to find it
+func (r *Room) saveReplay() {
+	r.mu.Lock()
+	rep := r.replay
+	r.replay = nil
+	r.mu.Unlock()
+
+	if rep == nil || r.replays == nil {
+		return
+	}
+
+	if err := r.replays.Save(rep); err != nil {
+		log.Printf("replay error: %s, ignoring.\n", err)
+		return
+	}
+
+	r.bus.Publish(&event.Event{
+		Type:   event.TypeReplaySaved,
+		Origin: event.OriginRoom(r.GetId()),
+		Payload: &ReplayPayload{
+			Id: rep.Id,
+		},
+	})
+}
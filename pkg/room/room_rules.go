@@ -1,6 +1,302 @@
 package room
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/gravity"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"github.com/nitwhiz/quadis-server/pkg/rotation"
+	"time"
+)
+
+const minFieldWidth = 4
+const maxFieldWidth = 40
+const minFieldHeight = 8
+const maxFieldHeight = 40
+const maxPlayers = 99
+const maxSpectators = 64
+const maxLockDelay = 5000
+const maxLockResets = 100
+const maxStartLevel = 99
+const maxPreviewCount = 6
+const maxGarbageDelay = 10000
+const maxReconnectGracePeriod = 300
+const maxCountdown = 10
+const maxBestOf = 15
+const maxIntermission = 60
+const maxTeams = 8
+
 type Rules struct {
-	BedrockEnabled bool
-	ItemsEnabled   bool
+	BedrockEnabled bool      `json:"bedrockEnabled"`
+	ItemsEnabled   bool      `json:"itemsEnabled"`
+	FieldWidth     int       `json:"fieldWidth"`
+	FieldHeight    int       `json:"fieldHeight"`
+	GravityCurve   string    `json:"gravityCurve"`
+	GravityTable   []float64 `json:"gravityTable"`
+	StartLevel     int       `json:"startLevel"`
+	RotationSystem string    `json:"rotationSystem"`
+	// LockDelay is the time in ms a grounded piece waits before locking
+	LockDelay  int `json:"lockDelay"`
+	LockResets int `json:"lockResets"`
+	// PreviewCount is the number of upcoming pieces shown to the players
+	PreviewCount int    `json:"previewCount"`
+	Randomizer   string `json:"randomizer"`
+	// RandomizerSequence is the fixed sequence of piece letters used by the sequence randomizer
+	RandomizerSequence string `json:"randomizerSequence"`
+	// GarbageMode decides whether attacks send solid bedrock or garbage rows with holes
+	GarbageMode  string `json:"garbageMode"`
+	GarbageHoles int    `json:"garbageHoles"`
+	// GarbageMessiness is the probability of the holes moving to other columns from one garbage row to the next
+	GarbageMessiness float64 `json:"garbageMessiness"`
+	// GarbageDelay is the time in ms received attacks wait before they can materialize
+	GarbageDelay int `json:"garbageDelay"`
+	// ItemInterval is the time between item drops in seconds
+	ItemInterval        int     `json:"itemInterval"`
+	ItemDropProbability float64 `json:"itemDropProbability"`
+	// CurfewTimeout is the inactivity in seconds after which the room is shut down
+	CurfewTimeout int `json:"curfewTimeout"`
+	MaxPlayers    int `json:"maxPlayers"`
+	MaxSpectators int `json:"maxSpectators"`
+	// ReconnectGracePeriod is the time in seconds a disconnected game is kept for its player to reconnect
+	ReconnectGracePeriod int `json:"reconnectGracePeriod"`
+	// ReadyQuorum is the share of players which have to be ready before the host can start
+	ReadyQuorum float64 `json:"readyQuorum"`
+	// Countdown is the time in seconds between the start and the first update of the games
+	Countdown int `json:"countdown"`
+	// BestOf is the number of rounds a match has at most, the first player to win the majority wins the match
+	BestOf int `json:"bestOf"`
+	// Intermission is the time in seconds between the end of a round and the countdown of the next one
+	Intermission int `json:"intermission"`
+	// Teams is the number of teams players can join, 0 means every player is on their own
+	Teams int `json:"teams"`
+	// Coop makes the players of a team share one field, every player controlling its own piece
+	Coop bool `json:"coop"`
+	// Mode is the game mode, the single player modes have no attacks, items or teams
+	Mode string `json:"mode"`
+}
+
+func DefaultRules() *Rules {
+	config := game.DefaultConfig()
+
+	return &Rules{
+		BedrockEnabled:       true,
+		ItemsEnabled:         true,
+		FieldWidth:           config.FieldWidth,
+		FieldHeight:          config.FieldHeight,
+		GravityCurve:         config.GravityCurve,
+		StartLevel:           config.StartLevel,
+		RotationSystem:       config.RotationSystem,
+		LockDelay:            config.LockDelay,
+		LockResets:           config.LockResets,
+		PreviewCount:         config.PreviewCount,
+		Randomizer:           config.Randomizer,
+		GarbageMode:          config.GarbageMode,
+		GarbageHoles:         config.GarbageHoles,
+		GarbageMessiness:     config.GarbageMessiness,
+		GarbageDelay:         config.GarbageDelay,
+		ItemInterval:         10,
+		ItemDropProbability:  .75,
+		CurfewTimeout:        15 * 60,
+		MaxPlayers:           16,
+		MaxSpectators:        8,
+		ReconnectGracePeriod: 30,
+		ReadyQuorum:          1,
+		Countdown:            3,
+		BestOf:               1,
+		Intermission:         5,
+		Teams:                0,
+		Coop:                 false,
+		Mode:                 config.Mode,
+	}
+}
+
+// ParseRules reads rules from a json document, missing fields are set to their defaults
+func ParseRules(data []byte) (*Rules, error) {
+	return DefaultRules().Merge(data)
+}
+
+// Merge returns a copy of the rules with the fields of the json document applied
+func (r *Rules) Merge(data []byte) (*Rules, error) {
+	rules := *r
+
+	if len(bytes.TrimSpace(data)) != 0 {
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, errors.New("malformed rules")
+		}
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func (r *Rules) Validate() error {
+	if r.FieldWidth < minFieldWidth || r.FieldWidth > maxFieldWidth {
+		return errors.New("field width out of range")
+	}
+
+	if r.FieldHeight < minFieldHeight || r.FieldHeight > maxFieldHeight {
+		return errors.New("field height out of range")
+	}
+
+	if _, err := gravity.New(r.GravityCurve, r.GravityTable); err != nil {
+		return err
+	}
+
+	if r.StartLevel < 0 || r.StartLevel > maxStartLevel {
+		return errors.New("start level out of range")
+	}
+
+	if _, err := rotation.Get(r.RotationSystem); err != nil {
+		return err
+	}
+
+	if r.LockDelay < 0 || r.LockDelay > maxLockDelay {
+		return errors.New("lock delay out of range")
+	}
+
+	if r.LockResets < 0 || r.LockResets > maxLockResets {
+		return errors.New("lock resets out of range")
+	}
+
+	if r.PreviewCount < 1 || r.PreviewCount > maxPreviewCount {
+		return errors.New("preview count out of range")
+	}
+
+	if _, err := piece.NewGeneratorFactory(r.Randomizer, r.RandomizerSequence); err != nil {
+		return err
+	}
+
+	if r.GarbageMode != game.GarbageModeBedrock && r.GarbageMode != game.GarbageModeGarbage {
+		return errors.New("unknown garbage mode")
+	}
+
+	if r.GarbageHoles < 1 || r.GarbageHoles >= r.FieldWidth {
+		return errors.New("garbage holes out of range")
+	}
+
+	if r.GarbageMessiness < 0 || r.GarbageMessiness > 1 {
+		return errors.New("garbage messiness out of range")
+	}
+
+	if r.GarbageDelay < 0 || r.GarbageDelay > maxGarbageDelay {
+		return errors.New("garbage delay out of range")
+	}
+
+	if r.ItemInterval < 1 {
+		return errors.New("item interval out of range")
+	}
+
+	if r.ItemDropProbability < 0 || r.ItemDropProbability > 1 {
+		return errors.New("item drop probability out of range")
+	}
+
+	if r.CurfewTimeout < 60 {
+		return errors.New("curfew timeout out of range")
+	}
+
+	if r.MaxPlayers < 1 || r.MaxPlayers > maxPlayers {
+		return errors.New("max players out of range")
+	}
+
+	if r.MaxSpectators < 0 || r.MaxSpectators > maxSpectators {
+		return errors.New("max spectators out of range")
+	}
+
+	if r.ReconnectGracePeriod < 0 || r.ReconnectGracePeriod > maxReconnectGracePeriod {
+		return errors.New("reconnect grace period out of range")
+	}
+
+	if r.ReadyQuorum <= 0 || r.ReadyQuorum > 1 {
+		return errors.New("ready quorum out of range")
+	}
+
+	if r.Countdown < 0 || r.Countdown > maxCountdown {
+		return errors.New("countdown out of range")
+	}
+
+	if r.BestOf < 1 || r.BestOf > maxBestOf {
+		return errors.New("best of out of range")
+	}
+
+	if r.Intermission < 0 || r.Intermission > maxIntermission {
+		return errors.New("intermission out of range")
+	}
+
+	if r.Teams < 0 || r.Teams == 1 || r.Teams > maxTeams {
+		return errors.New("teams out of range")
+	}
+
+	if r.Coop && !r.IsTeamMode() {
+		return errors.New("co-op needs teams")
+	}
+
+	if err := game.ValidateMode(r.Mode); err != nil {
+		return err
+	}
+
+	if game.IsSinglePlayerMode(r.Mode) && r.IsTeamMode() {
+		return errors.New("single player modes cannot have teams")
+	}
+
+	return nil
+}
+
+func (r *Rules) GetItemInterval() time.Duration {
+	return time.Second * time.Duration(r.ItemInterval)
+}
+
+func (r *Rules) GetCurfewTimeout() time.Duration {
+	return time.Second * time.Duration(r.CurfewTimeout)
+}
+
+func (r *Rules) GetReconnectGracePeriod() time.Duration {
+	return time.Second * time.Duration(r.ReconnectGracePeriod)
+}
+
+func (r *Rules) GetCountdown() time.Duration {
+	return time.Second * time.Duration(r.Countdown)
+}
+
+// IsVersus tells whether the players play against each other, only then attacks and items are distributed
+func (r *Rules) IsVersus() bool {
+	return r.Mode == game.ModeVersus
+}
+
+func (r *Rules) IsTeamMode() bool {
+	return r.Teams > 0
+}
+
+func (r *Rules) GetIntermission() time.Duration {
+	return time.Second * time.Duration(r.Intermission)
+}
+
+// GetRoundsToWin returns the number of round wins which decide the match
+func (r *Rules) GetRoundsToWin() int {
+	return r.BestOf/2 + 1
+}
+
+func (r *Rules) ToGameConfig() *game.Config {
+	return &game.Config{
+		FieldWidth:         r.FieldWidth,
+		FieldHeight:        r.FieldHeight,
+		GravityCurve:       r.GravityCurve,
+		GravityTable:       r.GravityTable,
+		StartLevel:         r.StartLevel,
+		RotationSystem:     r.RotationSystem,
+		LockDelay:          r.LockDelay,
+		LockResets:         r.LockResets,
+		PreviewCount:       r.PreviewCount,
+		Randomizer:         r.Randomizer,
+		RandomizerSequence: r.RandomizerSequence,
+		GarbageMode:        r.GarbageMode,
+		GarbageHoles:       r.GarbageHoles,
+		GarbageMessiness:   r.GarbageMessiness,
+		GarbageDelay:       r.GarbageDelay,
+		Mode:               r.Mode,
+	}
 }
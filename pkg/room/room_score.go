@@ -4,29 +4,156 @@ import (
 	"github.com/nitwhiz/quadis-server/pkg/event"
 	"github.com/nitwhiz/quadis-server/pkg/game"
 	"github.com/nitwhiz/quadis-server/pkg/score"
+	"sort"
 )
 
+// Knockout records a game which topped out and the game whose garbage landed in it last
+type Knockout struct {
+	GameId       string
+	KnockedOutBy string
+}
+
 type playerScorePayload struct {
-	Game  *game.Payload  `json:"game"`
-	Score *score.Payload `json:"score"`
+	Game      *game.Payload  `json:"game"`
+	Score     *score.Payload `json:"score"`
+	Placement int            `json:"placement"`
+	Knockouts int            `json:"knockouts"`
+	// KnockedOutBy is the id of the game which knocked this game out, empty if it survived or topped out on its own
+	KnockedOutBy string `json:"knockedOutBy"`
+	// SurvivalTime is the time in ms the game was running
+	SurvivalTime int64 `json:"survivalTime"`
+}
+
+type teamScorePayload struct {
+	Team      int      `json:"team"`
+	Score     int      `json:"score"`
+	Lines     int      `json:"lines"`
+	Knockouts int      `json:"knockouts"`
+	Placement int      `json:"placement"`
+	GameIds   []string `json:"gameIds"`
+}
+
+type ScoresPayload struct {
+	Scores []*playerScorePayload `json:"scores"`
+	// Teams is empty unless the room is in team mode
+	Teams []*teamScorePayload `json:"teams"`
+}
+
+func (r *Room) getKnockouts() []*Knockout {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*Knockout{}, r.knockouts...)
+}
+
+// getScores returns the scores ordered by placement: the survivors by score, followed by the others in reverse elimination order
+func (r *Room) getScores() []*playerScorePayload {
+	knockouts := r.getKnockouts()
+	games := r.GetGames()
+
+	knockoutCounts := map[string]int{}
+	knockedOutBy := map[string]string{}
+
+	for _, ko := range knockouts {
+		knockedOutBy[ko.GameId] = ko.KnockedOutBy
+
+		if ko.KnockedOutBy != "" && ko.KnockedOutBy != ko.GameId {
+			knockoutCounts[ko.KnockedOutBy] += 1
+		}
+	}
+
+	var survivors []*playerScorePayload
+	var eliminated []*playerScorePayload
+
+	newScore := func(g *game.Game) *playerScorePayload {
+		gameId := g.GetId()
+
+		return &playerScorePayload{
+			Game:         g.ToPayload(),
+			Score:        g.GetScore().ToPayload(),
+			Placement:    0,
+			Knockouts:    knockoutCounts[gameId],
+			KnockedOutBy: knockedOutBy[gameId],
+			SurvivalTime: g.GetSurvivalTime().Milliseconds(),
+		}
+	}
+
+	for gameId, g := range games {
+		if _, ok := knockedOutBy[gameId]; !ok {
+			survivors = append(survivors, newScore(g))
+		}
+	}
+
+	sort.SliceStable(survivors, func(i, j int) bool {
+		return survivors[i].Score.Score > survivors[j].Score.Score
+	})
+
+	for i := len(knockouts) - 1; i >= 0; i-- {
+		if g, ok := games[knockouts[i].GameId]; ok {
+			eliminated = append(eliminated, newScore(g))
+		}
+	}
+
+	scores := append(survivors, eliminated...)
+
+	for i, s := range scores {
+		s.Placement = i + 1
+	}
+
+	return scores
+}
+
+// getTeamScores sums up the scores per team, the teams are placed by their best placed member.
+// Co-op teams share their score, it is not summed up.
+func getTeamScores(scores []*playerScorePayload, coop bool) []*teamScorePayload {
+	teamScores := []*teamScorePayload{}
+	byTeam := map[int]*teamScorePayload{}
+
+	// scores are ordered by placement, so teams are created in the order of their best member
+	for _, s := range scores {
+		ts, ok := byTeam[s.Game.Team]
+
+		if !ok {
+			ts = &teamScorePayload{
+				Team:      s.Game.Team,
+				Placement: len(teamScores) + 1,
+				GameIds:   []string{},
+			}
+
+			byTeam[s.Game.Team] = ts
+			teamScores = append(teamScores, ts)
+		}
+
+		if coop {
+			ts.Score = s.Score.Score
+			ts.Lines = s.Score.Lines
+		} else {
+			ts.Score += s.Score.Score
+			ts.Lines += s.Score.Lines
+		}
+
+		ts.Knockouts += s.Knockouts
+		ts.GameIds = append(ts.GameIds, s.Game.Id)
+	}
+
+	return teamScores
 }
 
 func (r *Room) publishScores() {
-	r.gamesMutex.RLock()
-	defer r.gamesMutex.RUnlock()
+	scores := r.getScores()
 
-	var scores []*playerScorePayload
+	payload := ScoresPayload{
+		Scores: scores,
+		Teams:  []*teamScorePayload{},
+	}
 
-	for _, g := range r.games {
-		scores = append(scores, &playerScorePayload{
-			Game:  g.ToPayload(),
-			Score: g.GetScore().ToPayload(),
-		})
+	if rules := r.GetRules(); rules.IsTeamMode() {
+		payload.Teams = getTeamScores(scores, rules.Coop)
 	}
 
 	r.bus.Publish(&event.Event{
 		Type:    event.TypeRoomScores,
 		Origin:  event.OriginRoom(r.GetId()),
-		Payload: scores,
+		Payload: &payload,
 	})
 }
@@ -0,0 +1,64 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/communication"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/item"
+)
+
+type GameSnapshotPayload struct {
+	*game.SnapshotPayload
+	Item      *string `json:"item"`
+	Affection string  `json:"affection"`
+}
+
+// SnapshotPayload is the full state of the room, sent to everyone who needs to catch up
+type SnapshotPayload struct {
+	Room    *Payload               `json:"room"`
+	Started bool                   `json:"started"`
+	Games   []*GameSnapshotPayload `json:"games"`
+	Targets map[string]string      `json:"targets"`
+	// Strategies are the targeting strategies chosen by the players
+	Strategies map[string]string `json:"strategies"`
+	Match      *MatchPayload     `json:"match"`
+}
+
+func (r *Room) ToSnapshotPayload() *SnapshotPayload {
+	s := SnapshotPayload{
+		Room:       r.ToPayload(),
+		Started:    r.IsStarted(),
+		Games:      []*GameSnapshotPayload{},
+		Targets:    map[string]string{},
+		Strategies: map[string]string{},
+		Match:      r.getMatchPayload(),
+	}
+
+	for gId, g := range r.GetGames() {
+		gs := GameSnapshotPayload{
+			SnapshotPayload: g.ToSnapshotPayload(),
+			Item:            nil,
+			Affection:       item.TypeNone,
+		}
+
+		if r.itemDistribution != nil {
+			gs.Item = r.itemDistribution.GetItemType(gId)
+			gs.Affection = r.itemDistribution.GetAffection(gId)
+		}
+
+		s.Games = append(s.Games, &gs)
+	}
+
+	if r.targets != nil {
+		tp := r.targets.ToPayload()
+
+		s.Targets = tp.Targets
+		s.Strategies = tp.Strategies
+	}
+
+	return &s
+}
+
+func (r *Room) sendRoomSnapshot(c *communication.Connection) error {
+	return r.send(c, event.TypeRoomSnapshot, r.ToSnapshotPayload())
+}
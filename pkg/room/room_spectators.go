@@ -0,0 +1,87 @@
+package room
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/nitwhiz/quadis-server/pkg/communication"
+	"time"
+)
+
+// addSpectator subscribes the connection to the room without creating a game for it
+func (r *Room) addSpectator(c *communication.Connection) error {
+	spectatorId := uuid.NewString()
+
+	r.spectatorsMutex.Lock()
+
+	if len(r.spectators) >= r.GetRules().MaxSpectators {
+		r.spectatorsMutex.Unlock()
+
+		return errors.New("too many spectators")
+	}
+
+	r.spectators[spectatorId] = c
+
+	r.spectatorsMutex.Unlock()
+
+	if err := r.SpectatorHandshakeAck(c); err != nil {
+		return err
+	}
+
+	r.bus.Subscribe(spectatorId, c)
+
+	if err := r.sendRoomSnapshot(c); err != nil {
+		return err
+	}
+
+	go r.startSpectatorReader(spectatorId, c)
+
+	return nil
+}
+
+func (r *Room) isSpectator(spectatorId string) bool {
+	r.spectatorsMutex.RLock()
+	defer r.spectatorsMutex.RUnlock()
+
+	_, ok := r.spectators[spectatorId]
+
+	return ok
+}
+
+// startSpectatorReader passes the room commands of a spectator to the room
+func (r *Room) startSpectatorReader(spectatorId string, c *communication.Connection) {
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	for r.isSpectator(spectatorId) {
+		select {
+		case <-r.ctx.Done():
+			return
+		case cmd := <-c.GetInputChannel():
+			r.HandleCommand(c, nil, cmd)
+			break
+		case <-time.After(time.Millisecond * 250):
+			break
+		}
+	}
+}
+
+func (r *Room) RemoveSpectator(c *communication.Connection) {
+	r.spectatorsMutex.Lock()
+	defer r.spectatorsMutex.Unlock()
+
+	for spectatorId, sc := range r.spectators {
+		if sc == c {
+			r.bus.Unsubscribe(spectatorId)
+			delete(r.spectators, spectatorId)
+
+			return
+		}
+	}
+}
+
+func (r *Room) GetSpectatorsCount() int {
+	r.spectatorsMutex.RLock()
+	defer r.spectatorsMutex.RUnlock()
+
+	return len(r.spectators)
+}
@@ -0,0 +1,51 @@
+package room
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpectatorLimit(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.MaxSpectators = 2
+	})
+
+	srv, errs := newTestServer(t, r)
+
+	var spectators []*testClient
+
+	for i := 0; i < 2; i++ {
+		c, ack := join(t, srv, &HelloResponseMessage{Spectator: true})
+		expectConnected(t, errs)
+
+		if !ack.Spectator || ack.ControlledGame != nil || ack.ResumeToken != "" {
+			t.Fatalf("expected a spectator acknowledgement, got %+v", ack)
+		}
+
+		spectators = append(spectators, c)
+	}
+
+	connectTestClient(t, srv, &HelloResponseMessage{Spectator: true})
+
+	if err := awaitConnectError(t, errs); err == nil {
+		t.Fatal("expected the third spectator to be rejected")
+	}
+
+	if count, games := r.GetSpectatorsCount(), len(r.GetGames()); count != 2 || games != 0 {
+		t.Fatalf("expected 2 spectators and no games, got %d and %d", count, games)
+	}
+
+	// a leaving spectator makes room for the next one
+	_ = spectators[0].ws.Close()
+
+	for deadline := time.Now().Add(time.Second * 5); r.GetSpectatorsCount() != 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the spectator to be removed")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	join(t, srv, &HelloResponseMessage{Spectator: true})
+	expectConnected(t, errs)
+}
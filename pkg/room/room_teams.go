@@ -0,0 +1,143 @@
+package room
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"sort"
+)
+
+const minCoopTeamSize = 2
+const maxCoopTeamSize = 4
+
+// TeamPayload is sent to join a team, the host may move other players by naming their game
+type TeamPayload struct {
+	Team   int    `json:"team"`
+	GameId string `json:"gameId"`
+}
+
+func (r *Room) SetTeam(g *game.Game, p *TeamPayload) error {
+	if g == nil {
+		return errors.New("spectators cannot join teams")
+	}
+
+	if r.IsStarted() || r.match.IsInProgress() {
+		return ErrStarted
+	}
+
+	if p.Team < 0 || p.Team > r.GetRules().Teams {
+		return errors.New("unknown team")
+	}
+
+	target := g
+
+	if p.GameId != "" && p.GameId != g.GetId() {
+		if !g.IsHost() {
+			return ErrNotHost
+		}
+
+		if target = r.GetGame(p.GameId); target == nil {
+			return errors.New("unknown game")
+		}
+	}
+
+	target.GetPlayer().SetTeam(p.Team)
+
+	r.bus.Publish(&event.Event{
+		Type:    event.TypeTeamUpdate,
+		Origin:  event.OriginRoom(r.GetId()),
+		Payload: target.ToPayload(),
+	})
+
+	return nil
+}
+
+// checkTeams makes sure everyone is in a team and there are at least two teams to play against each other
+func (r *Room) checkTeams() error {
+	if !r.GetRules().IsTeamMode() {
+		return nil
+	}
+
+	rules := r.GetRules()
+	teams := map[int]int{}
+
+	for _, g := range r.GetGames() {
+		team := g.GetTeam()
+
+		if team == 0 {
+			return errors.New("not every player is in a team")
+		}
+
+		teams[team] += 1
+	}
+
+	if !rules.Coop {
+		if len(teams) < 2 {
+			return errors.New("not enough teams")
+		}
+
+		return nil
+	}
+
+	// a single co-op team may play on its own
+	for _, size := range teams {
+		if size < minCoopTeamSize || size > maxCoopTeamSize {
+			return errors.New("co-op teams need two to four players")
+		}
+
+		if size*piece.BodyWidth > rules.FieldWidth {
+			return errors.New("field too narrow for the co-op team")
+		}
+	}
+
+	return nil
+}
+
+// AreOpponents tells if a may attack b, in team mode only players of different teams are opponents
+func (r *Room) AreOpponents(a *game.Game, b *game.Game) bool {
+	if a == nil || b == nil || a.GetId() == b.GetId() {
+		return false
+	}
+
+	if r.GetRules().IsTeamMode() {
+		return a.GetTeam() != b.GetTeam()
+	}
+
+	return true
+}
+
+// formCoopGroups lets the players of every team share a field in co-op, otherwise every game gets its own field again.
+// It returns the groups, they have to be started as a whole. The games have to be locked by the caller.
+func (r *Room) formCoopGroups(seed int64) ([]*game.Shared, error) {
+	rules := r.GetRules()
+	teams := map[int][]string{}
+
+	var groups []*game.Shared
+
+	for gameId, g := range r.games {
+		if err := g.SetShared(nil); err != nil {
+			return nil, err
+		}
+
+		if rules.Coop {
+			teams[g.GetTeam()] = append(teams[g.GetTeam()], gameId)
+		}
+	}
+
+	for _, memberIds := range teams {
+		sort.Strings(memberIds)
+
+		shared := game.NewShared(rules.ToGameConfig(), seed, memberIds)
+
+		for _, gameId := range memberIds {
+			if err := r.games[gameId].SetShared(shared); err != nil {
+				return nil, err
+			}
+		}
+
+		groups = append(groups, shared)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,148 @@
+package room
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"github.com/nitwhiz/quadis-server/pkg/game"
+	"github.com/nitwhiz/quadis-server/pkg/item"
+	"testing"
+	"time"
+)
+
+// startTeams starts headless games in two teams, the games 0 and 1 play in team 1, the games 2 and 3 in team 2
+func startTeams(t *testing.T, r *Room) []*game.Game {
+	games := addHeadlessGames(t, r, 4)
+
+	for i, g := range games {
+		g.GetPlayer().SetTeam(i/2 + 1)
+	}
+
+	if err := r.Start(0); err != nil {
+		t.Fatal(err)
+	}
+
+	return games
+}
+
+func setTargets(r *Room, targets map[string]string) {
+	r.targets.mu.Lock()
+	defer r.targets.mu.Unlock()
+
+	r.targets.targetMap = targets
+}
+
+func TestRandomTargetsAreOpponents(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Teams = 2
+	})
+
+	startTeams(t, r)
+
+	for i := 0; i < 50; i++ {
+		r.targets.Randomize()
+
+		for sourceId, targetId := range r.targets.ToPayload().Targets {
+			if sourceId != targetId && !r.AreOpponents(r.GetGame(sourceId), r.GetGame(targetId)) {
+				t.Fatalf("expected %s to target an opponent, it targets its teammate %s", sourceId, targetId)
+			}
+		}
+	}
+}
+
+func TestManualTargetMustBeOpponent(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Teams = 2
+	})
+
+	games := startTeams(t, r)
+
+	if err := r.SetTargetingStrategy(games[0], &TargetingPayload{Strategy: StrategyManual, GameId: "1"}); err == nil {
+		t.Fatal("expected a teammate to be rejected as target")
+	}
+
+	if err := r.SetTargetingStrategy(games[0], &TargetingPayload{Strategy: StrategyManual, GameId: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if targetId := r.targets.GetTargetGameId("0"); targetId != "2" {
+		t.Fatalf("expected 0 to target 2, got %s", targetId)
+	}
+}
+
+func TestItemsSkipTeammates(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Teams = 2
+	})
+
+	srv, errs := newTestServer(t, r)
+	c := connectTestClient(t, srv, &HelloResponseMessage{Spectator: true})
+
+	expectConnected(t, errs)
+
+	games := startTeams(t, r)
+
+	setTargets(r, map[string]string{"0": "1", "2": "0"})
+
+	tornado := item.NewTornado()
+
+	tornado.Activate(games[0], r)
+	tornado.Activate(games[2], r)
+
+	// the tornado on the teammate does nothing, the first one to hit is the one of the opponent
+	if e := c.await(event.TypeItemAffectionUpdate); e.Origin.Id != "2" {
+		t.Fatalf("expected the item of 2 to hit, got the one of %s", e.Origin.Id)
+	}
+}
+
+func TestBedrockSkipsTeammates(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Teams = 2
+		rules.BedrockEnabled = true
+	})
+
+	games := startTeams(t, r)
+
+	setTargets(r, map[string]string{"0": "1", "2": "0"})
+
+	r.bedrockDistribution.Channel <- &game.Bedrock{Amount: 2, SourceId: "0"}
+	r.bedrockDistribution.Channel <- &game.Bedrock{Amount: 3, SourceId: "2"}
+
+	// the bedrock is distributed in order, once the opponent got its lines the teammate would have gotten them as well
+	for deadline := time.Now().Add(time.Second * 5); games[0].ToSnapshotPayload().Incoming.Amount != 3; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the opponent to receive the bedrock")
+		}
+
+		games[0].Step()
+	}
+
+	games[1].Step()
+
+	if amount := games[1].ToSnapshotPayload().Incoming.Amount; amount != 0 {
+		t.Fatalf("expected the teammate to receive no bedrock, got %d lines", amount)
+	}
+}
+
+func TestLastTeamStanding(t *testing.T) {
+	r := newTestRoom(t, func(rules *Rules) {
+		rules.Teams = 2
+	})
+
+	games := startTeams(t, r)
+
+	games[0].ToggleOver(true)
+	games[2].ToggleOver(true)
+
+	if r.isRoundOver() {
+		t.Fatal("expected the round to go on while both teams have a player standing")
+	}
+
+	games[3].ToggleOver(true)
+
+	if !r.isRoundOver() {
+		t.Fatal("expected the round to be over with one team standing")
+	}
+
+	if winner := r.getRoundWinnerKey(); winner != "1" {
+		t.Fatalf("expected team 1 to win, got %s", winner)
+	}
+}
@@ -0,0 +1,184 @@
+package room
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/nitwhiz/quadis-server/pkg/event"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient is a websocket client of a room, as a browser would connect it
+type testClient struct {
+	t       *testing.T
+	ws      *websocket.Conn
+	pending []*testEvent
+}
+
+type testEvent struct {
+	Type    string          `json:"type"`
+	Origin  *event.Origin   `json:"origin"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// newTestServer serves the room on a websocket, the errors of the handshakes are sent to the returned channel
+func newTestServer(t *testing.T, r *Room) (*httptest.Server, chan error) {
+	upgrader := websocket.Upgrader{}
+	errs := make(chan error, 16)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ws, err := upgrader.Upgrade(w, req, nil)
+
+		if err != nil {
+			return
+		}
+
+		errs <- r.Connect(ws)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, errs
+}
+
+// connectTestClient dials the server and answers the hello of the room
+func connectTestClient(t *testing.T, srv *httptest.Server, hrm *HelloResponseMessage) *testClient {
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+
+	if err != nil {
+		t.Fatalf("unable to dial: %s", err)
+	}
+
+	t.Cleanup(func() {
+		_ = ws.Close()
+	})
+
+	c := &testClient{t: t, ws: ws}
+
+	c.await(event.TypeHello)
+
+	if err := ws.WriteJSON(hrm); err != nil {
+		t.Fatalf("unable to answer hello: %s", err)
+	}
+
+	return c
+}
+
+// join connects a player and returns the acknowledgement of the room
+func join(t *testing.T, srv *httptest.Server, hrm *HelloResponseMessage) (*testClient, *HelloAckPayload) {
+	c := connectTestClient(t, srv, hrm)
+
+	var ack HelloAckPayload
+
+	c.awaitPayload(event.TypeHelloAck, &ack)
+
+	return c, &ack
+}
+
+// next reads the next event, window events are unpacked
+func (c *testClient) next() (*testEvent, error) {
+	for len(c.pending) == 0 {
+		_ = c.ws.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+		var e testEvent
+
+		if err := c.ws.ReadJSON(&e); err != nil {
+			return nil, err
+		}
+
+		if e.Type != event.TypeWindow {
+			return &e, nil
+		}
+
+		var w struct {
+			Events []*testEvent `json:"events"`
+		}
+
+		if err := json.Unmarshal(e.Payload, &w); err != nil {
+			return nil, err
+		}
+
+		c.pending = w.Events
+	}
+
+	e := c.pending[0]
+	c.pending = c.pending[1:]
+
+	return e, nil
+}
+
+// await skips events until one of the type arrives
+func (c *testClient) await(eventType string) *testEvent {
+	c.t.Helper()
+
+	for {
+		e, err := c.next()
+
+		if err != nil {
+			c.t.Fatalf("expected %s, got error: %s", eventType, err)
+		}
+
+		if e.Type == eventType {
+			return e
+		}
+	}
+}
+
+func (c *testClient) awaitPayload(eventType string, payload any) {
+	c.t.Helper()
+
+	if err := json.Unmarshal(c.await(eventType).Payload, payload); err != nil {
+		c.t.Fatalf("malformed %s payload: %s", eventType, err)
+	}
+}
+
+func (c *testClient) send(msg string) {
+	c.t.Helper()
+
+	if err := c.ws.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		c.t.Fatalf("unable to send %s: %s", msg, err)
+	}
+}
+
+// awaitConnectError returns the error of the next handshake
+func awaitConnectError(t *testing.T, errs chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the handshake to finish")
+	}
+
+	return nil
+}
+
+func expectConnected(t *testing.T, errs chan error) {
+	t.Helper()
+
+	if err := awaitConnectError(t, errs); err != nil {
+		t.Fatalf("expected the handshake to succeed, got %s", err)
+	}
+}
+
+// newTestRoom creates a room without the random bedrock and items, the rules are changed by configure
+func newTestRoom(t *testing.T, configure func(rules *Rules)) *Room {
+	rules := DefaultRules()
+	rules.BedrockEnabled = false
+	rules.ItemsEnabled = false
+	rules.Countdown = 0
+
+	if configure != nil {
+		configure(rules)
+	}
+
+	r := New(rules, nil)
+
+	t.Cleanup(r.Shutdown)
+
+	return r
+}
@@ -0,0 +1,33 @@
+package rotation
+
+import (
+	"errors"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+)
+
+const SystemSRS = "srs"
+const SystemClassic = "classic"
+
+// Kick is an offset tried when rotating a piece, the y axis points down like in the field
+type Kick struct {
+	X int
+	Y int
+}
+
+// System decides which kicks are tried, in order, when rotating a piece
+type System interface {
+	GetKicks(p *piece.Piece, from piece.Rotation, to piece.Rotation) []Kick
+}
+
+var systems = map[string]System{
+	SystemSRS:     NewSRS(),
+	SystemClassic: NewClassic(),
+}
+
+func Get(name string) (System, error) {
+	if s, ok := systems[name]; ok {
+		return s, nil
+	}
+
+	return nil, errors.New("unknown rotation system")
+}
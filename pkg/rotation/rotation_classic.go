@@ -0,0 +1,16 @@
+package rotation
+
+import "github.com/nitwhiz/quadis-server/pkg/piece"
+
+// Classic never kicks, a rotation either fits in place or fails
+type Classic struct{}
+
+var noKicks = []Kick{{0, 0}}
+
+func NewClassic() *Classic {
+	return &Classic{}
+}
+
+func (c *Classic) GetKicks(*piece.Piece, piece.Rotation, piece.Rotation) []Kick {
+	return noKicks
+}
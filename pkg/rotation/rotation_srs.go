@@ -0,0 +1,76 @@
+package rotation
+
+import "github.com/nitwhiz/quadis-server/pkg/piece"
+
+type transition struct {
+	from piece.Rotation
+	to   piece.Rotation
+}
+
+type kickTable map[transition][]Kick
+
+// SRS is the Super Rotation System as specified by the guideline
+type SRS struct {
+	jlstz     kickTable
+	i         kickTable
+	halfTurns []Kick
+}
+
+// srsKicks converts kicks from the guideline notation, where the y axis points up
+func srsKicks(offsets ...[2]int) []Kick {
+	var kicks []Kick
+
+	for _, o := range offsets {
+		kicks = append(kicks, Kick{
+			X: o[0],
+			Y: -o[1],
+		})
+	}
+
+	return kicks
+}
+
+func NewSRS() *SRS {
+	return &SRS{
+		jlstz: kickTable{
+			{piece.RotationSpawn, piece.RotationRight}:   srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{-1, 1}, [2]int{0, -2}, [2]int{-1, -2}),
+			{piece.RotationRight, piece.RotationSpawn}:   srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{1, -1}, [2]int{0, 2}, [2]int{1, 2}),
+			{piece.RotationRight, piece.RotationReverse}: srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{1, -1}, [2]int{0, 2}, [2]int{1, 2}),
+			{piece.RotationReverse, piece.RotationRight}: srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{-1, 1}, [2]int{0, -2}, [2]int{-1, -2}),
+			{piece.RotationReverse, piece.RotationLeft}:  srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{1, 1}, [2]int{0, -2}, [2]int{1, -2}),
+			{piece.RotationLeft, piece.RotationReverse}:  srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{-1, -1}, [2]int{0, 2}, [2]int{-1, 2}),
+			{piece.RotationLeft, piece.RotationSpawn}:    srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{-1, -1}, [2]int{0, 2}, [2]int{-1, 2}),
+			{piece.RotationSpawn, piece.RotationLeft}:    srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{1, 1}, [2]int{0, -2}, [2]int{1, -2}),
+		},
+		i: kickTable{
+			{piece.RotationSpawn, piece.RotationRight}:   srsKicks([2]int{0, 0}, [2]int{-2, 0}, [2]int{1, 0}, [2]int{-2, -1}, [2]int{1, 2}),
+			{piece.RotationRight, piece.RotationSpawn}:   srsKicks([2]int{0, 0}, [2]int{2, 0}, [2]int{-1, 0}, [2]int{2, 1}, [2]int{-1, -2}),
+			{piece.RotationRight, piece.RotationReverse}: srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{2, 0}, [2]int{-1, 2}, [2]int{2, -1}),
+			{piece.RotationReverse, piece.RotationRight}: srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{-2, 0}, [2]int{1, -2}, [2]int{-2, 1}),
+			{piece.RotationReverse, piece.RotationLeft}:  srsKicks([2]int{0, 0}, [2]int{2, 0}, [2]int{-1, 0}, [2]int{2, 1}, [2]int{-1, -2}),
+			{piece.RotationLeft, piece.RotationReverse}:  srsKicks([2]int{0, 0}, [2]int{-2, 0}, [2]int{1, 0}, [2]int{-2, -1}, [2]int{1, 2}),
+			{piece.RotationLeft, piece.RotationSpawn}:    srsKicks([2]int{0, 0}, [2]int{1, 0}, [2]int{-2, 0}, [2]int{1, -2}, [2]int{-2, 1}),
+			{piece.RotationSpawn, piece.RotationLeft}:    srsKicks([2]int{0, 0}, [2]int{-1, 0}, [2]int{2, 0}, [2]int{-1, 2}, [2]int{2, -1}),
+		},
+		// the guideline does not specify 180° rotations, these kicks are kept simple on purpose
+		halfTurns: srsKicks([2]int{0, 0}, [2]int{0, 1}, [2]int{1, 0}, [2]int{-1, 0}),
+	}
+}
+
+func (s *SRS) GetKicks(p *piece.Piece, from piece.Rotation, to piece.Rotation) []Kick {
+	if p.Token == piece.TokenO {
+		return noKicks
+	}
+
+	table := s.jlstz
+
+	if p.Token == piece.TokenI {
+		table = s.i
+	}
+
+	if kicks, ok := table[transition{from, to}]; ok {
+		return kicks
+	}
+
+	return s.halfTurns
+}
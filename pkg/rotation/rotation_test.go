@@ -0,0 +1,70 @@
+package rotation
+
+import (
+	"github.com/nitwhiz/quadis-server/pkg/field"
+	"github.com/nitwhiz/quadis-server/pkg/piece"
+	"testing"
+)
+
+// in srs, reversing a rotation tries the inverted kicks of that rotation
+func TestSRSKicksAreSymmetric(t *testing.T) {
+	srs := NewSRS()
+
+	for _, table := range []kickTable{srs.jlstz, srs.i} {
+		for tr, kicks := range table {
+			reverseKicks := table[transition{tr.to, tr.from}]
+
+			if len(reverseKicks) != len(kicks) {
+				t.Fatalf("kick count of %d->%d does not match its reverse", tr.from, tr.to)
+			}
+
+			for i, k := range kicks {
+				if reverseKicks[i].X != -k.X || reverseKicks[i].Y != -k.Y {
+					t.Fatalf("kick #%d of %d->%d is not the inverse of its reverse", i, tr.from, tr.to)
+				}
+			}
+		}
+	}
+}
+
+func TestSRSWallKick(t *testing.T) {
+	srs := NewSRS()
+
+	f := field.New(&field.Settings{
+		Seed:   0,
+		Width:  10,
+		Height: 20,
+	})
+
+	// vertical I piece flush against the right wall, occupying column 9
+	x, y := 7, 5
+	from := piece.RotationRight
+
+	if !f.CanPutPiece(&piece.I, from, x, y) {
+		t.Fatal("expected the I piece to fit against the wall")
+	}
+
+	if f.CanPutPiece(&piece.I, piece.RotationReverse, x, y) {
+		t.Fatal("expected the unkicked rotation to collide with the wall")
+	}
+
+	for _, k := range srs.GetKicks(&piece.I, from, piece.RotationReverse) {
+		if f.CanPutPiece(&piece.I, piece.RotationReverse, x+k.X, y+k.Y) {
+			if k.X >= 0 {
+				t.Fatalf("expected the piece to be kicked away from the wall, got %d,%d", k.X, k.Y)
+			}
+
+			return
+		}
+	}
+
+	t.Fatal("expected a kick to succeed")
+}
+
+func TestClassicDoesNotKick(t *testing.T) {
+	kicks := NewClassic().GetKicks(&piece.T, piece.RotationSpawn, piece.RotationRight)
+
+	if len(kicks) != 1 || kicks[0].X != 0 || kicks[0].Y != 0 {
+		t.Fatalf("expected only the zero kick, got %v", kicks)
+	}
+}
@@ -1,32 +1,64 @@
 package score
 
 import (
+	"github.com/nitwhiz/quadis-server/pkg/attack"
 	"github.com/nitwhiz/quadis-server/pkg/dirty"
 	"github.com/nitwhiz/quadis-server/pkg/metrics"
 	"sync"
 )
 
+// linesPerLevel is the number of lines needed to advance one level
+const linesPerLevel = 10
+
 type Score struct {
-	score int
-	lines int
-	Dirty *dirty.Dirtiness
-	mu    *sync.RWMutex
+	score      int
+	lines      int
+	startLevel int
+	Dirty      *dirty.Dirtiness
+	mu         *sync.RWMutex
 }
 
 type Payload struct {
 	Score int `json:"score"`
 	Lines int `json:"lines"`
+	Level int `json:"level"`
 }
 
-func New() *Score {
+func New(startLevel int) *Score {
 	return &Score{
-		score: 0,
-		lines: 0,
-		Dirty: dirty.New(),
-		mu:    &sync.RWMutex{},
+		score:      0,
+		lines:      0,
+		startLevel: startLevel,
+		Dirty:      dirty.New(),
+		mu:         &sync.RWMutex{},
 	}
 }
 
+func (s *Score) getLevel() int {
+	return s.startLevel + s.lines/linesPerLevel
+}
+
+func (s *Score) GetLines() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lines
+}
+
+func (s *Score) GetScore() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.score
+}
+
+func (s *Score) GetLevel() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getLevel()
+}
+
 func (s *Score) ToPayload() *Payload {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -34,6 +66,7 @@ func (s *Score) ToPayload() *Payload {
 	return &Payload{
 		Score: s.score,
 		Lines: s.lines,
+		Level: s.getLevel(),
 	}
 }
 
@@ -62,15 +95,58 @@ func getScoreByLineCount(l int) int {
 	}
 }
 
-func (s *Score) AddLines(l int) {
+func getScoreBySpin(l int, spin attack.Spin) int {
+	switch spin {
+	case attack.SpinMini:
+		return 100 * (1 << l)
+	case attack.SpinFull:
+		return 400 * (l + 1)
+	default:
+		return getScoreByLineCount(l)
+	}
+}
+
+func getPerfectClearScore(l int) int {
+	switch l {
+	case 1:
+		return 800
+	case 2:
+		return 1200
+	case 3:
+		return 1800
+	default:
+		return 2000
+	}
+}
+
+func getScoreByClear(c *attack.Clear) int {
+	n := getScoreBySpin(c.Lines, c.Spin)
+
+	if c.BackToBack {
+		n = n * 3 / 2
+	}
+
+	if c.Combo > 0 {
+		n += 50 * c.Combo
+	}
+
+	if c.PerfectClear {
+		n += getPerfectClearScore(c.Lines)
+	}
+
+	return n
+}
+
+func (s *Score) AddClear(c *attack.Clear) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if l == 0 {
+	if c.Lines == 0 && c.Spin == attack.SpinNone {
 		return
 	}
 
-	n := getScoreByLineCount(l)
+	n := getScoreByClear(c)
+	l := c.Lines
 
 	s.score += n
 	s.lines += l
@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/nitwhiz/quadis-server/pkg/game"
 	"github.com/nitwhiz/quadis-server/pkg/metrics"
+	"github.com/nitwhiz/quadis-server/pkg/playback"
+	"github.com/nitwhiz/quadis-server/pkg/replay"
 	"github.com/nitwhiz/quadis-server/pkg/room"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"io"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,17 +31,56 @@ var upgrader = websocket.Upgrader{
 type Server struct {
 	rooms      map[string]*room.Room
 	roomsMutex *sync.Mutex
+	replays    *replay.Store
+	// ctx ends with the server, the playbacks of replays run in it as they outlive their requests
+	ctx      context.Context
+	shutdown context.CancelFunc
 }
 
 func New() *Server {
+	replayDir := os.Getenv("QUADIS_REPLAY_DIR")
+
+	if replayDir == "" {
+		replayDir = replay.DefaultDir
+	}
+
+	ctx, shutdown := context.WithCancel(context.Background())
+
 	return &Server{
 		rooms:      map[string]*room.Room{},
 		roomsMutex: &sync.Mutex{},
+		replays:    replay.NewStore(replayDir, getReplayRetention()),
+		ctx:        ctx,
+		shutdown:   shutdown,
 	}
 }
 
-func (s *Server) createRoom() *room.Room {
-	r := room.New()
+// getReplayRetention reads the retention of replays from QUADIS_REPLAY_MAX_COUNT and QUADIS_REPLAY_MAX_AGE,
+// 0 keeps any number or age. Malformed values are ignored.
+func getReplayRetention() *replay.Retention {
+	retention := replay.DefaultRetention()
+
+	if v := os.Getenv("QUADIS_REPLAY_MAX_COUNT"); v != "" {
+		if maxCount, err := strconv.Atoi(v); err == nil {
+			retention.MaxCount = maxCount
+		} else {
+			log.Printf("malformed QUADIS_REPLAY_MAX_COUNT: %s, ignoring.\n", err)
+		}
+	}
+
+	if v := os.Getenv("QUADIS_REPLAY_MAX_AGE"); v != "" {
+		if maxAge, err := time.ParseDuration(v); err == nil {
+			retention.MaxAge = maxAge
+		} else {
+			log.Printf("malformed QUADIS_REPLAY_MAX_AGE: %s, ignoring.\n", err)
+		}
+	}
+
+	return retention
+}
+
+func (s *Server) createRoom(rules *room.Rules) *room.Room {
+	r := room.New(rules, s.replays)
 
 	s.roomsMutex.Lock()
 	defer s.roomsMutex.Unlock()
@@ -75,7 +122,7 @@ func (s *Server) connect(roomId string, resp http.ResponseWriter, req *http.Requ
 			return err
 		}
 
-		return r.CreateGame(conn)
+		return r.Connect(conn)
 	}
 
 	return errors.New("room_not_found")
@@ -113,12 +160,31 @@ func (s *Server) StartMetricsCollector() {
 }
 
 func (s *Server) Start() error {
+	defer s.shutdown()
+
 	r := gin.Default()
 
 	r.Use(cors.Default())
 
 	r.POST("/rooms", func(c *gin.Context) {
-		r := s.createRoom()
+		requestBody, err := io.ReadAll(c.Request.Body)
+
+		if err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		rules, err := room.ParseRules(requestBody)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+
+			return
+		}
+
+		r := s.createRoom(rules)
 
 		c.JSON(http.StatusOK, gin.H{
 			"roomId": r.GetId(),
@@ -146,7 +212,28 @@ func (s *Server) Start() error {
 			return
 		}
 
-		r.Start()
+		// the resume token of the host authorizes the start
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		var hostGame *game.Game
+
+		if token != "" {
+			hostGame = r.GetGameByResumeToken(token)
+		}
+
+		if err := r.StartAsHost(hostGame); err != nil {
+			status := http.StatusForbidden
+
+			if errors.Is(err, room.ErrStarted) {
+				status = http.StatusConflict
+			}
+
+			c.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+
+			return
+		}
 
 		c.Status(http.StatusNoContent)
 	})
@@ -184,6 +271,53 @@ func (s *Server) Start() error {
 		}
 	})
 
+	r.GET("/replays/:replayId", func(c *gin.Context) {
+		replayId := c.Param("replayId")
+
+		path, err := s.replays.GetPath(replayId)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+
+			return
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "replay not found",
+			})
+
+			return
+		}
+
+		c.FileAttachment(path, replayId+replay.FileExtension)
+	})
+
+	r.GET("/replays/:replayId/socket", func(c *gin.Context) {
+		rep, err := s.replays.Load(c.Param("replayId"))
+
+		if err != nil {
+			status := http.StatusBadRequest
+
+			if errors.Is(err, replay.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+
+			c.AbortWithStatus(status)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+
+		if err != nil {
+			return
+		}
+
+		_ = playback.Connect(s.ctx, rep, conn)
+	})
+
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	if gin.IsDebugging() {